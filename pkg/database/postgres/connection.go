@@ -1,6 +1,10 @@
 package postgres
 
-import "time"
+import (
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
 
 // Connection holds PostgreSQL connection parameters
 type Connection struct {
@@ -11,6 +15,10 @@ type Connection struct {
 	Database       string
 	SSLMode        string
 	ConnectionPool *ConnectionPool
+
+	// QueryTracer, when set, receives every completed query with its
+	// duration, such as the tracer returned by otel.PgxTracer
+	QueryTracer pgx.Logger
 }
 
 // ConnectionPool holds PostgreSQL connection pool configuration
@@ -31,6 +31,10 @@ func NewDatabase(config Connection) (*Database, error) {
 		return nil, fmt.Errorf("parse pgx config: %w", err)
 	}
 
+	if config.QueryTracer != nil {
+		pgxConfig.ConnConfig.Logger = config.QueryTracer
+	}
+
 	ctx := context.Background()
 	connection, err := RetryConnection(ctx, pgxConfig, config.ConnectionPool.ValidationQuery, config.ConnectionPool.RetryAttempts, config.ConnectionPool.RetryInterval)
 	if err != nil {
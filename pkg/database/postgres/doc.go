@@ -65,4 +65,10 @@
 //	        RetryInterval:        5 * time.Second,
 //	    },
 //	}
+//
+// Query Tracing:
+// Connection.QueryTracer receives every completed query, batch and copy with
+// its duration, for example to trace queries with OpenTelemetry:
+//
+//	config.QueryTracer = otel.PgxTracer(otel.Options{})
 package postgres
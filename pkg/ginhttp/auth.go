@@ -0,0 +1,202 @@
+package ginhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTokenRefreshBefore is the default of
+// ClientCredentialsConfig.RefreshBefore
+const defaultTokenRefreshBefore = time.Minute
+
+// BearerToken returns middleware sending the token in the Authorization
+// header of every request
+func BearerToken(token string) Middleware {
+	return SetHeader("Authorization", "Bearer "+token)
+}
+
+// APIKey returns middleware sending the key in the given header of every
+// request, such as X-API-Key
+func APIKey(header, key string) Middleware {
+	return SetHeader(header, key)
+}
+
+// SetHeader returns middleware setting a header on every request, replacing
+// the value set by the caller
+func SetHeader(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set(key, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// ClientCredentialsConfig configures the OAuth2 client credentials grant
+type ClientCredentialsConfig struct {
+	// TokenURL is the token endpoint of the authorization server.
+	// Example: "https://auth.example.com/oauth2/token"
+	TokenURL string
+
+	// ClientID and ClientSecret authenticate the client with HTTP Basic
+	// authentication
+	ClientID     string
+	ClientSecret string
+
+	// Scopes are the requested scopes, if any
+	Scopes []string
+
+	// Params are additional token request parameters, such as audience
+	Params url.Values
+
+	// RefreshBefore is how long before its expiry a token is replaced,
+	// capped at half its lifetime. Defaults to 1 minute.
+	RefreshBefore time.Duration
+
+	// HTTPClient sends the token requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// ClientCredentials returns middleware authenticating requests with an
+// OAuth2 access token obtained with the client credentials grant. The token
+// is cached and fetched again ahead of its expiry, or after a 401 response.
+func ClientCredentials(config ClientCredentialsConfig) Middleware {
+	source := &tokenSource{config: config, now: time.Now}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := source.token(req.Context())
+			if err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp.StatusCode == http.StatusUnauthorized {
+				// The token may have been revoked before its expiry
+				source.invalidate(token)
+			}
+			return resp, err
+		})
+	}
+}
+
+// tokenResponse is the successful response of a token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenSource caches the access token of a client
+type tokenSource struct {
+	config ClientCredentialsConfig
+	now    func() time.Time
+
+	// mu is held while fetching, so that concurrent requests wait for a
+	// single token request
+	mu        sync.Mutex
+	cached    string
+	refreshAt time.Time
+}
+
+// token returns the cached token, fetching a new one when it is missing or
+// about to expire
+func (s *tokenSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != "" && (s.refreshAt.IsZero() || s.now().Before(s.refreshAt)) {
+		return s.cached, nil
+	}
+
+	fetchedAt := s.now()
+	resp, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.cached, s.refreshAt = resp.AccessToken, time.Time{}
+	if resp.ExpiresIn > 0 {
+		lifetime := time.Duration(resp.ExpiresIn) * time.Second
+		refreshBefore := s.config.RefreshBefore
+		if refreshBefore <= 0 {
+			refreshBefore = defaultTokenRefreshBefore
+		}
+		s.refreshAt = fetchedAt.Add(lifetime - min(refreshBefore, lifetime/2))
+	}
+	return s.cached, nil
+}
+
+// invalidate discards the cached token, unless it was already replaced
+func (s *tokenSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached == token {
+		s.cached = ""
+	}
+}
+
+// fetch requests a token from the token endpoint
+func (s *tokenSource) fetch(ctx context.Context) (*tokenResponse, error) {
+	form := url.Values{}
+	for key, values := range s.config.Params {
+		form[key] = values
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	client := s.config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting token: %w", newHTTPError(resp))
+	}
+
+	data, err := readLimited(resp.Body, defaultMaxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("reading token response: %w", err)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("decoding token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+	return &token, nil
+}
@@ -0,0 +1,297 @@
+package ginhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer returns a token endpoint issuing numbered tokens that
+// expire after expiresIn seconds, and a counter of the tokens issued
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "orders" || clientSecret != "s3cret" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":"invalid_client"}`)
+			return
+		}
+
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+		assert.Equal(t, "billing", r.PostForm.Get("audience"))
+
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &issued
+}
+
+// newAuthorizationServer returns a server answering 401 to the tokens in
+// revoked, and recording the Authorization header of the other requests
+func newAuthorizationServer(t *testing.T, received *[]string, revoked ...string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		for _, token := range revoked {
+			if authorization == "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		*received = append(*received, authorization)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestStaticCredentials(t *testing.T) {
+	testCases := []struct {
+		name       string
+		middleware Middleware
+		header     string
+		expected   string
+	}{
+		{
+			name:       "bearer token",
+			middleware: BearerToken("abc"),
+			header:     "Authorization",
+			expected:   "Bearer abc",
+		},
+		{
+			name:       "api key",
+			middleware: APIKey("X-API-Key", "key-123"),
+			header:     "X-API-Key",
+			expected:   "key-123",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Get(tc.header)
+			}))
+			defer server.Close()
+
+			client := NewClientWithOptions(nil, Options{Middleware: []Middleware{tc.middleware}})
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+
+			// When
+			resp, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			// Then
+			assert.Equal(t, tc.expected, received)
+			assert.Empty(t, req.Header.Get(tc.header), "the caller's request must not be modified")
+		})
+	}
+}
+
+func TestClientCredentialsCachesToken(t *testing.T) {
+	// Given
+	tokenServer, issued := newTokenServer(t, 3600)
+	var received []string
+	server := newAuthorizationServer(t, &received)
+
+	client := NewClientWithOptions(nil, Options{Middleware: []Middleware{
+		ClientCredentials(ClientCredentialsConfig{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "orders",
+			ClientSecret: "s3cret",
+			Scopes:       []string{"read", "write"},
+			Params:       map[string][]string{"audience": {"billing"}},
+		}),
+	}})
+
+	// When
+	for range 3 {
+		resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	// Then
+	assert.Equal(t, int32(1), issued.Load())
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-1"}, received)
+}
+
+func TestClientCredentialsRefreshesToken(t *testing.T) {
+	// Given
+	tokenServer, issued := newTokenServer(t, 300)
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	source := &tokenSource{
+		config: ClientCredentialsConfig{
+			TokenURL:      tokenServer.URL,
+			ClientID:      "orders",
+			ClientSecret:  "s3cret",
+			Scopes:        []string{"read", "write"},
+			Params:        map[string][]string{"audience": {"billing"}},
+			RefreshBefore: time.Minute,
+		},
+		now: func() time.Time { return now },
+	}
+
+	first, err := source.token(context.Background())
+	require.NoError(t, err)
+
+	// When
+	now = now.Add(3*time.Minute + 59*time.Second)
+	beforeRefresh, err := source.token(context.Background())
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+	afterRefresh, err := source.token(context.Background())
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, "token-1", first)
+	assert.Equal(t, "token-1", beforeRefresh)
+	assert.Equal(t, "token-2", afterRefresh, "the token is replaced a minute before it expires")
+	assert.Equal(t, int32(2), issued.Load())
+}
+
+func TestClientCredentialsReplacesRejectedToken(t *testing.T) {
+	// Given
+	tokenServer, issued := newTokenServer(t, 3600)
+	var received []string
+	server := newAuthorizationServer(t, &received, "token-1")
+
+	client := NewClientWithOptions(nil, Options{Middleware: []Middleware{
+		ClientCredentials(ClientCredentialsConfig{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "orders",
+			ClientSecret: "s3cret",
+			Scopes:       []string{"read", "write"},
+			Params:       map[string][]string{"audience": {"billing"}},
+		}),
+	}})
+
+	// When
+	statuses := make([]int, 0, 2)
+	for range 2 {
+		resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		statuses = append(statuses, resp.StatusCode)
+	}
+
+	// Then
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusOK}, statuses)
+	assert.Equal(t, []string{"Bearer token-2"}, received)
+	assert.Equal(t, int32(2), issued.Load())
+}
+
+func TestClientCredentialsTokenErrors(t *testing.T) {
+	// Given
+	tokenServer, _ := newTokenServer(t, 3600)
+	client := NewClientWithOptions(nil, Options{Middleware: []Middleware{
+		ClientCredentials(ClientCredentialsConfig{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "orders",
+			ClientSecret: "wrong",
+		}),
+	}})
+
+	body := &trackingBody{Reader: strings.NewReader(`{"id":42}`)}
+
+	// When
+	_, err := client.Request(context.Background(), http.MethodPost, "http://example.invalid", body, nil)
+
+	// Then
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.StatusCode)
+	assert.JSONEq(t, `{"error":"invalid_client"}`, string(httpErr.Body))
+	assert.True(t, body.closed, "the body of the unsent request is closed")
+}
+
+func TestHMACSigner(t *testing.T) {
+	// Given
+	secret := []byte("shared-secret")
+	var received http.Header
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		receivedBody = string(body)
+	}))
+	defer server.Close()
+
+	signer := &requestSigner{
+		config: HMACConfig{KeyID: "orders", Secret: secret},
+		now:    func() time.Time { return time.Unix(1704110400, 0) },
+	}
+	client := NewClientWithOptions(nil, Options{Middleware: []Middleware{signer.middleware}})
+
+	// A reader without GetBody, which the signer must still send in full
+	body := io.MultiReader(strings.NewReader(`{"id":`), strings.NewReader(`42}`))
+
+	// When
+	resp, err := client.Request(context.Background(), http.MethodPost, server.URL+"/orders?dry_run=true", body, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// Then
+	assert.Equal(t, `{"id":42}`, receivedBody)
+	assert.Equal(t, "orders", received.Get(SignatureKeyIDHeader))
+	assert.Equal(t, BodyDigest([]byte(`{"id":42}`)), received.Get(ContentSHA256Header))
+
+	timestamp := received.Get(SignatureTimestampHeader)
+	assert.Equal(t, "1704110400", timestamp)
+
+	expected := Signature(secret, http.MethodPost, "/orders?dry_run=true", timestamp, received.Get(ContentSHA256Header))
+	assert.Equal(t, expected, received.Get(SignatureHeader))
+}
+
+func TestHMACSignerClosesBodyWhenReadingFails(t *testing.T) {
+	// Given
+	sent := false
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		sent = true
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(nil, Options{Middleware: []Middleware{
+		HMACSigner(HMACConfig{Secret: []byte("shared-secret")}),
+	}})
+
+	body := &trackingBody{Reader: strings.NewReader(`{"id":42}`)}
+	req, err := http.NewRequest(http.MethodPost, server.URL, body)
+	require.NoError(t, err)
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, errors.New("body already consumed")
+	}
+
+	// When
+	_, err = client.Do(context.Background(), req)
+
+	// Then
+	require.ErrorContains(t, err, "reading body to sign")
+	assert.False(t, sent)
+	assert.True(t, body.closed, "the body of the unsent request is closed")
+}
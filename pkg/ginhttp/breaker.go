@@ -0,0 +1,343 @@
+package ginhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of CircuitBreakerPolicy
+const (
+	defaultBreakerConsecutiveFailures = 5
+	defaultBreakerFailureRatio        = 0.5
+	defaultBreakerMinRequests         = 10
+	defaultBreakerWindow              = time.Minute
+	defaultBreakerOpenTimeout         = 30 * time.Second
+	defaultBreakerHalfOpenRequests    = 1
+)
+
+// CircuitState is the state of the circuit breaker of a host
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through while counting their failures
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects requests without sending them
+	CircuitOpen
+
+	// CircuitHalfOpen lets a few probe requests through to find out
+	// whether the host has recovered
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// MarshalText encodes the state by name, so that it reads well in health
+// endpoint responses
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ErrCircuitOpen matches, with errors.Is, the errors of requests rejected by
+// an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned for requests rejected by an open circuit
+// breaker, wrapped in a *url.Error by http.Client. Use errors.As to read it:
+//
+//	var openErr *ginhttp.CircuitOpenError
+//	if errors.As(err, &openErr) {
+//	    // openErr.Host, openErr.RetryAfter ...
+//	}
+type CircuitOpenError struct {
+	// Host is the host whose circuit is open
+	Host string
+
+	// RetryAfter is the time left until the circuit lets a probe request
+	// through, or zero when it is already probing
+	RetryAfter time.Duration
+}
+
+// Error returns a message naming the host
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %s is open", e.Host)
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreakerPolicy configures the circuit breakers of a client, one per
+// host. A closed circuit opens when either threshold is reached, rejects
+// requests for OpenTimeout, then lets HalfOpenRequests probe requests
+// through: the circuit closes if they all succeed and opens again otherwise.
+//
+// Network errors, except cancellations by the caller, and 5xx responses are
+// failures.
+type CircuitBreakerPolicy struct {
+	// ConsecutiveFailures opens the circuit after this many failures in a
+	// row. Defaults to 5.
+	ConsecutiveFailures int
+
+	// FailureRatio opens the circuit when this ratio of the requests of
+	// the current window failed, once MinRequests were sent. A ratio of 1
+	// only opens the circuit when every request failed. Defaults to 0.5.
+	FailureRatio float64
+
+	// MinRequests is the number of requests of the current window below
+	// which FailureRatio is not checked. Defaults to 10.
+	MinRequests int
+
+	// Window is the period after which the counts used for FailureRatio
+	// are reset. Defaults to 1 minute.
+	Window time.Duration
+
+	// OpenTimeout is how long an open circuit rejects requests before
+	// probing the host. Defaults to 30s.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of probe requests let through by a
+	// half-open circuit, all of which must succeed to close it. Defaults
+	// to 1.
+	HalfOpenRequests int
+}
+
+// withDefaults returns the policy with unset fields defaulted
+func (p CircuitBreakerPolicy) withDefaults() CircuitBreakerPolicy {
+	if p.ConsecutiveFailures <= 0 {
+		p.ConsecutiveFailures = defaultBreakerConsecutiveFailures
+	}
+	if p.FailureRatio <= 0 {
+		p.FailureRatio = defaultBreakerFailureRatio
+	}
+	if p.MinRequests <= 0 {
+		p.MinRequests = defaultBreakerMinRequests
+	}
+	if p.Window <= 0 {
+		p.Window = defaultBreakerWindow
+	}
+	if p.OpenTimeout <= 0 {
+		p.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if p.HalfOpenRequests <= 0 {
+		p.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	return p
+}
+
+// circuit holds the state of the circuit breaker of a host
+type circuit struct {
+	state CircuitState
+
+	// generation changes with every state change, so that the outcome of
+	// requests let through in an earlier state is ignored
+	generation uint64
+
+	// Counts of the closed state
+	windowStart         time.Time
+	requests            int
+	failures            int
+	consecutiveFailures int
+
+	// Open and half-open states
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+// transition is a change of state, logged after the lock is released
+type transition struct {
+	host     string
+	from, to CircuitState
+}
+
+// circuitBreakers holds the circuit breakers of a client by host
+type circuitBreakers struct {
+	policy CircuitBreakerPolicy
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// newCircuitBreakers creates the circuit breakers of a client
+func newCircuitBreakers(policy CircuitBreakerPolicy) *circuitBreakers {
+	return &circuitBreakers{
+		policy:   policy.withDefaults(),
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// states returns the state of every circuit by host
+func (b *circuitBreakers) states() map[string]CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]CircuitState, len(b.circuits))
+	for host, c := range b.circuits {
+		states[host] = c.state
+	}
+	return states
+}
+
+// allow reports whether a request to host may be sent, returning the
+// generation of the circuit to pass to done
+func (b *circuitBreakers) allow(host string) (uint64, *transition, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{windowStart: now}
+		b.circuits[host] = c
+	}
+
+	var change *transition
+	switch c.state {
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= b.policy.Window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+
+	case CircuitOpen:
+		if wait := c.openedAt.Add(b.policy.OpenTimeout).Sub(now); wait > 0 {
+			return 0, nil, &CircuitOpenError{Host: host, RetryAfter: wait}
+		}
+		change = b.setState(host, c, CircuitHalfOpen, now)
+		fallthrough
+
+	case CircuitHalfOpen:
+		if c.probes >= b.policy.HalfOpenRequests {
+			return 0, change, &CircuitOpenError{Host: host}
+		}
+		c.probes++
+	}
+
+	return c.generation, change, nil
+}
+
+// done records the outcome of a request let through by allow. Requests
+// canceled by the caller are neither successes nor failures.
+func (b *circuitBreakers) done(host string, generation uint64, failed, canceled bool) *transition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[host]
+	if c.generation != generation {
+		return nil
+	}
+
+	now := b.now()
+	switch c.state {
+	case CircuitClosed:
+		if canceled {
+			return nil
+		}
+
+		c.requests++
+		if !failed {
+			c.consecutiveFailures = 0
+			return nil
+		}
+		c.failures++
+		c.consecutiveFailures++
+
+		if c.consecutiveFailures >= b.policy.ConsecutiveFailures ||
+			(c.requests >= b.policy.MinRequests && float64(c.failures)/float64(c.requests) >= b.policy.FailureRatio) {
+			return b.setState(host, c, CircuitOpen, now)
+		}
+
+	case CircuitHalfOpen:
+		switch {
+		case canceled:
+			// The probe did not tell anything, so another one may be sent
+			c.probes--
+		case failed:
+			return b.setState(host, c, CircuitOpen, now)
+		default:
+			c.successes++
+			if c.successes >= b.policy.HalfOpenRequests {
+				return b.setState(host, c, CircuitClosed, now)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setState moves the circuit to a new state and resets its counts
+func (b *circuitBreakers) setState(host string, c *circuit, state CircuitState, now time.Time) *transition {
+	change := &transition{host: host, from: c.state, to: state}
+
+	*c = circuit{
+		state:       state,
+		generation:  c.generation + 1,
+		windowStart: now,
+		openedAt:    now,
+	}
+
+	return change
+}
+
+// newCircuitBreakerRoundTripper creates a new round tripper that rejects
+// requests to hosts whose circuit is open
+func newCircuitBreakerRoundTripper(next http.RoundTripper, breakers *circuitBreakers) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host := req.URL.Host
+
+		generation, change, err := breakers.allow(host)
+		logTransition(req.Context(), change)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+
+		canceled := errors.Is(err, context.Canceled)
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		logTransition(req.Context(), breakers.done(host, generation, failed, canceled))
+
+		return resp, err
+	})
+}
+
+// logTransition logs a change of circuit state with the trace ID of the
+// request that caused it
+func logTransition(ctx context.Context, change *transition) {
+	if change == nil {
+		return
+	}
+
+	level := slog.LevelWarn
+	if change.to == CircuitClosed {
+		level = slog.LevelInfo
+	}
+
+	slog.Log(ctx, level, "circuit breaker state changed",
+		"trace_id", extractSpan(ctx).TraceID,
+		"server.address", change.host,
+		"circuit.previous_state", change.from.String(),
+		"circuit.state", change.to.String(),
+	)
+}
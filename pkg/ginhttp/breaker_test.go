@@ -0,0 +1,269 @@
+package ginhttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a settable clock for circuit breaker tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// trackingBody is a request body recording whether it was closed
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// newTestBreakers returns circuit breakers driven by a fake clock
+func newTestBreakers(policy CircuitBreakerPolicy) (*circuitBreakers, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	breakers := newCircuitBreakers(policy)
+	breakers.now = clock.Now
+	return breakers, clock
+}
+
+// send records a request to host with the given outcome, reporting whether
+// it was let through
+func send(breakers *circuitBreakers, host string, failed bool) bool {
+	generation, _, err := breakers.allow(host)
+	if err != nil {
+		return false
+	}
+	breakers.done(host, generation, failed, false)
+	return true
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	server, attempts := newFlakyServer(t, nil,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	host := server.Listener.Addr().String()
+	client := NewClientWithOptions(&http.Client{}, Options{
+		CircuitBreaker: &CircuitBreakerPolicy{ConsecutiveFailures: 3},
+	})
+
+	for range 3 {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	// When
+	_, err := client.Get(server.URL)
+
+	// Then
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, host, openErr.Host)
+	assert.Positive(t, openErr.RetryAfter)
+
+	assert.Equal(t, int32(3), attempts.Load(), "the rejected request is not sent")
+	assert.Equal(t, map[string]CircuitState{host: CircuitOpen}, client.CircuitStates())
+
+	rec.AssertContains("WARN", "circuit breaker state changed", map[string]any{
+		"server.address":         host,
+		"circuit.previous_state": "closed",
+		"circuit.state":          "open",
+	})
+	rec.AssertContains("ERROR", "outgoing request failed", map[string]any{
+		"server.address": host,
+	})
+}
+
+func TestCircuitBreakerOpensOnFailureRatio(t *testing.T) {
+	// Given
+	breakers, _ := newTestBreakers(CircuitBreakerPolicy{
+		ConsecutiveFailures: 100,
+		FailureRatio:        0.5,
+		MinRequests:         4,
+	})
+
+	// When
+	for _, failed := range []bool{false, true, false, true} {
+		require.True(t, send(breakers, "api", failed))
+	}
+
+	// Then
+	assert.Equal(t, CircuitOpen, breakers.states()["api"])
+}
+
+func TestCircuitBreakerResetsCountsEveryWindow(t *testing.T) {
+	// Given
+	breakers, clock := newTestBreakers(CircuitBreakerPolicy{
+		ConsecutiveFailures: 100,
+		FailureRatio:        0.5,
+		MinRequests:         4,
+		Window:              time.Minute,
+	})
+	for _, failed := range []bool{true, true, false} {
+		require.True(t, send(breakers, "api", failed))
+	}
+
+	// When
+	clock.now = clock.now.Add(time.Minute)
+	require.True(t, send(breakers, "api", false))
+
+	// Then
+	assert.Equal(t, CircuitClosed, breakers.states()["api"])
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	testCases := []struct {
+		name          string
+		probeFailed   bool
+		expectedState CircuitState
+	}{
+		{
+			name:          "closes when the probe succeeds",
+			expectedState: CircuitClosed,
+		},
+		{
+			name:          "opens again when the probe fails",
+			probeFailed:   true,
+			expectedState: CircuitOpen,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			breakers, clock := newTestBreakers(CircuitBreakerPolicy{
+				ConsecutiveFailures: 1,
+				OpenTimeout:         time.Minute,
+			})
+			require.True(t, send(breakers, "api", true))
+			require.False(t, send(breakers, "api", false), "the open circuit rejects requests")
+
+			clock.now = clock.now.Add(time.Minute)
+
+			// When
+			generation, change, err := breakers.allow("api")
+			require.NoError(t, err)
+			_, _, concurrentErr := breakers.allow("api")
+			breakers.done("api", generation, tc.probeFailed, false)
+
+			// Then
+			assert.Equal(t, &transition{host: "api", from: CircuitOpen, to: CircuitHalfOpen}, change)
+			assert.ErrorIs(t, concurrentErr, ErrCircuitOpen, "a single probe is let through")
+			assert.Equal(t, tc.expectedState, breakers.states()["api"])
+		})
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledRequests(t *testing.T) {
+	// Given
+	breakers, clock := newTestBreakers(CircuitBreakerPolicy{ConsecutiveFailures: 1})
+	require.True(t, send(breakers, "api", true))
+	clock.now = clock.now.Add(time.Hour)
+
+	// When
+	generation, _, err := breakers.allow("api")
+	require.NoError(t, err)
+	breakers.done("api", generation, true, true)
+
+	// Then
+	assert.Equal(t, CircuitHalfOpen, breakers.states()["api"])
+	assert.True(t, send(breakers, "api", false), "another probe is let through")
+	assert.Equal(t, CircuitClosed, breakers.states()["api"])
+}
+
+func TestCircuitBreakerIgnoresOutcomesOfEarlierStates(t *testing.T) {
+	// Given
+	breakers, _ := newTestBreakers(CircuitBreakerPolicy{ConsecutiveFailures: 1})
+	slow, _, err := breakers.allow("api")
+	require.NoError(t, err)
+	require.True(t, send(breakers, "api", true))
+
+	// When
+	breakers.done("api", slow, false, false)
+
+	// Then
+	assert.Equal(t, CircuitOpen, breakers.states()["api"])
+}
+
+func TestRetryDoesNotRetryOpenCircuits(t *testing.T) {
+	// Given
+	var attempts int
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return nil, &CircuitOpenError{Host: req.URL.Host}
+	})
+	transport := newRetryRoundTripper(next, RetryPolicy{InitialBackoff: time.Millisecond})
+
+	// When
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	// Then
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestCircuitBreakerClosesBodyOfRejectedRequests(t *testing.T) {
+	// Given
+	breakers, _ := newTestBreakers(CircuitBreakerPolicy{ConsecutiveFailures: 1})
+	require.True(t, send(breakers, "api.example.com", true))
+
+	next := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("a request to an open circuit must not be sent")
+		return nil, nil
+	})
+	transport := newCircuitBreakerRoundTripper(next, breakers)
+
+	body := &trackingBody{Reader: strings.NewReader(`{"id":42}`)}
+	req, err := http.NewRequest(http.MethodPost, "http://api.example.com/orders", body)
+	require.NoError(t, err)
+
+	// When
+	_, err = transport.RoundTrip(req)
+
+	// Then
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.True(t, body.closed, "the body of a rejected request is closed")
+}
+
+func TestCircuitStates(t *testing.T) {
+	t.Run("without circuit breaker", func(t *testing.T) {
+		// Given
+		client := NewClient(&http.Client{})
+
+		// When
+		states := client.CircuitStates()
+
+		// Then
+		assert.Nil(t, states)
+	})
+
+	t.Run("encoded by name", func(t *testing.T) {
+		// Given
+		states := map[string]CircuitState{"a": CircuitClosed, "b": CircuitOpen, "c": CircuitHalfOpen}
+
+		// When
+		body, err := json.Marshal(states)
+
+		// Then
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a":"closed","b":"open","c":"half-open"}`, string(body))
+	})
+}
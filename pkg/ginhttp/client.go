@@ -5,44 +5,142 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
 	"github.com/gin-gonic/gin"
 )
 
 // Client wraps http.Client with tracing and logging capabilities
 type Client struct {
 	*http.Client
+
+	breakers    *circuitBreakers
+	maxResponse int64
 }
 
-// NewClient creates a new HTTP client with tracing and logging middleware
+// NewClient creates a new HTTP client with tracing, logging and metrics
+// middleware. The base client is copied, not modified, and a nil base client
+// stands for an empty http.Client.
 func NewClient(baseClient *http.Client) *Client {
-	if baseClient == nil {
-		baseClient = http.DefaultClient
+	return NewClientWithOptions(baseClient, Options{})
+}
+
+// NewClientWithOptions creates a new HTTP client with tracing, logging and
+// metrics middleware, and the optional behaviors enabled in opts. The base
+// client is copied, not modified.
+//
+// Requests go through the middleware in the following order, the first one
+// receiving the request from the caller:
+//  1. Retries (Options.Retry)
+//  2. Options.Tracing, once per attempt
+//  3. Trace propagation and logging, once per attempt
+//  4. Options.Middleware, in order
+//  5. Rate and concurrency limits (Options.RateLimit)
+//  6. Circuit breakers (Options.CircuitBreaker)
+//  7. Metrics
+//  8. The transport of the base client
+func NewClientWithOptions(baseClient *http.Client, opts Options) *Client {
+	client := &Client{Client: &http.Client{}, maxResponse: opts.MaxResponseBytes}
+	if baseClient != nil {
+		*client.Client = *baseClient
+	}
+
+	transport := newMetricsRoundTripper(client.Transport, metrics.Default())
+
+	// Requests rejected by an open circuit are logged, but not counted in
+	// the metrics as they are never sent
+	if opts.CircuitBreaker != nil {
+		client.breakers = newCircuitBreakers(*opts.CircuitBreaker)
+		transport = newCircuitBreakerRoundTripper(transport, client.breakers)
+	}
+
+	// Limits wait above the circuit breakers, so that requests given up
+	// while waiting do not count as failures of the host
+	if opts.RateLimit != nil {
+		transport = newRateLimitRoundTripper(transport, newRateLimiter(*opts.RateLimit, metrics.Default()))
+	}
+
+	for _, middleware := range slices.Backward(opts.Middleware) {
+		transport = middleware(transport)
+	}
+
+	transport = newLoggingRoundTripper(transport)
+
+	if opts.Tracing != nil {
+		transport = opts.Tracing(transport)
 	}
 
-	baseClient.Transport = newLoggingRoundTripper(baseClient.Transport)
-	return &Client{Client: baseClient}
+	// Retries wrap the logging middleware so that every attempt is logged
+	if opts.Retry != nil {
+		transport = newRetryRoundTripper(transport, *opts.Retry)
+	}
+
+	client.Transport = transport
+	return client
 }
 
-// OutgoingRequest performs an outgoing HTTP request with "outgoing" attributes to be logged.
-func (c *Client) OutgoingRequest(ctx *gin.Context, method, url string, body io.Reader, headers map[string]string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(
-		context.WithValue(ctx.Request.Context(), ginContextKey, ctx),
-		method,
-		url,
-		body,
-	)
+// CircuitStates returns the state of the circuit breaker of every host the
+// client sent requests to, for health endpoints. It returns nil when the
+// client has no circuit breaker.
+func (c *Client) CircuitStates() map[string]CircuitState {
+	if c.breakers == nil {
+		return nil
+	}
+	return c.breakers.states()
+}
+
+// Do sends the request with ctx as its context. The outgoing request
+// continues the trace found in ctx, which may be a *gin.Context or any
+// context carrying a span of pkg/tracectx or a trace ID of pkg/log.
+func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return c.Client.Do(req.WithContext(requestContext(ctx)))
+}
+
+// Request creates and sends a request with ctx as its context, like Do
+func (c *Client) Request(ctx context.Context, method, url string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(requestContext(ctx), method, url, body)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set headers if provided
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return c.Client.Do(req)
+}
+
+// OutgoingRequest performs an outgoing HTTP request with "outgoing" attributes to be logged.
+func (c *Client) OutgoingRequest(ctx *gin.Context, method, url string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	header := make(http.Header, len(headers))
 	for key, value := range headers {
-		req.Header.Set(key, value)
+		header.Set(key, value)
 	}
 
-	return c.Do(req)
+	return c.Request(ctx, method, url, body, header)
+}
+
+// requestContext returns the context of an outgoing request. The Gin context
+// is kept in the request context of Gin handlers, for trace propagation.
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		return context.WithValue(c.Request.Context(), ginContextKey, c)
+	}
+	return ctx
+}
+
+// ContextWithRequestSpan returns a copy of ctx carrying the span of an
+// outgoing request, created by a tracing transport wrapping the client. The
+// request is logged and propagated with this span, instead of a new child
+// span of the caller's trace.
+func ContextWithRequestSpan(ctx context.Context, span tracectx.SpanContext) context.Context {
+	return context.WithValue(ctx, requestSpanKey, span)
 }
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -52,6 +150,14 @@ func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
 
+// closeRequestBody closes the body of a request that is not sent, as an
+// http.RoundTripper must close it even on errors
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
 // NewLoggingRoundTripper creates a new round tripper that logs outgoing requests
 func newLoggingRoundTripper(next http.RoundTripper) http.RoundTripper {
 	if next == nil {
@@ -60,52 +166,95 @@ func newLoggingRoundTripper(next http.RoundTripper) http.RoundTripper {
 
 	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
 		start := time.Now()
-		traceID := extractTraceID(req.Context())
 
-		if traceID != "" {
-			req.Header.Set("X-Trace-ID", traceID)
+		// Each outgoing request is a new span of the caller's trace, unless
+		// a tracing transport already created it
+		span, ok := req.Context().Value(requestSpanKey).(tracectx.SpanContext)
+		if !ok {
+			span = extractSpan(req.Context()).Child()
 		}
 
+		// A RoundTripper must not modify the request, so the trace headers
+		// are set on a copy
+		req = req.Clone(req.Context())
+		tracectx.Inject(req.Header, span)
+
 		resp, err := next.RoundTrip(req)
-		return outgoing(req, err, resp, traceID, time.Since(start))
+		return outgoing(req, err, resp, span, time.Since(start))
 	})
 }
 
-func extractTraceID(ctx context.Context) string {
-	if c, exists := ctx.Value(ginContextKey).(*gin.Context); exists {
-		if id, exists := c.Get("X-Trace-ID"); exists {
-			if traceId, ok := id.(string); ok {
-				return traceId
-			}
+// extractSpan returns the span of the incoming request that triggered the
+// outgoing request, or an empty span context to start a new trace
+func extractSpan(ctx context.Context) tracectx.SpanContext {
+	if span, ok := tracectx.FromContext(ctx); ok {
+		return span
+	}
+
+	c, ok := ctx.Value(ginContextKey).(*gin.Context)
+	if !ok {
+		// If not found, try the parent context
+		if c, ok = ctx.(*gin.Context); !ok {
+			return spanFromLogContext(ctx)
 		}
 	}
 
-	// If not found, try to get from the parent context
-	if parent, ok := ctx.(*gin.Context); ok {
-		if id, exists := parent.Get("X-Trace-ID"); exists {
-			if traceId, ok := id.(string); ok {
-				return traceId
-			}
+	// The span stored by the logger middleware in the request context
+	if c.Request != nil {
+		if span, ok := tracectx.FromContext(c.Request.Context()); ok {
+			return span
 		}
 	}
 
-	// Last resort: check if it's in request headers
-	if gctx, ok := ctx.(*gin.Context); ok {
-		return gctx.GetHeader("X-Trace-ID")
+	// IDs stored in the Gin context by other middleware
+	if traceID := c.GetString("X-Trace-ID"); traceID != "" {
+		return tracectx.SpanContext{TraceID: traceID, SpanID: c.GetString("X-Span-ID"), Sampled: true}
+	}
+
+	// Last resort: the incoming request headers
+	if c.Request != nil {
+		span, _ := tracectx.Extract(c.Request.Header)
+		return span
 	}
 
-	return ""
+	return tracectx.SpanContext{}
+}
+
+// spanFromLogContext returns the IDs set with the ContextWith* functions of
+// pkg/log, for callers outside of Gin handlers
+func spanFromLogContext(ctx context.Context) tracectx.SpanContext {
+	traceID := log.TraceIDFromContext(ctx)
+	if traceID == "" {
+		return tracectx.SpanContext{}
+	}
+	return tracectx.SpanContext{TraceID: traceID, SpanID: log.SpanIDFromContext(ctx), Sampled: true}
 }
 
-func outgoing(req *http.Request, err error, resp *http.Response, traceID string, duration time.Duration) (*http.Response, error) {
+func outgoing(req *http.Request, err error, resp *http.Response, span tracectx.SpanContext, duration time.Duration) (*http.Response, error) {
 	attrs := []any{
-		"trace_id", traceID,
+		"trace_id", span.TraceID,
+		"span_id", span.SpanID,
 		"http.request.method", req.Method,
 		"http.route", req.URL.Path,
 		"server.address", req.URL.Host,
 		"http.response.latency", duration.String(),
 	}
 
+	if span.ParentSpanID != "" {
+		attrs = append(attrs, "parent_span_id", span.ParentSpanID)
+	}
+
+	// Set on retried attempts
+	if resendCount, ok := req.Context().Value(resendCountKey).(int); ok {
+		attrs = append(attrs, "http.request.resend_count", resendCount)
+	}
+
+	// Query strings may carry credentials, so they are masked with the
+	// redactor of the default pkg/log logger
+	if query := req.URL.RawQuery; query != "" {
+		attrs = append(attrs, "url.query", log.Default().Redactor().Query(query))
+	}
+
 	if err != nil {
 		if resp != nil && resp.StatusCode >= 400 {
 			attrs = append(attrs, "http.response.status_code", resp.StatusCode)
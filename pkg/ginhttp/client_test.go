@@ -1,12 +1,19 @@
 package ginhttp
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -215,3 +222,182 @@ func TestOutgoingRequestPreservesTraceID(t *testing.T) {
 	// Then
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
+
+func TestOutgoingRequestPropagatesTraceparent(t *testing.T) {
+	// Given
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	incoming := tracectx.SpanContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	}
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+	ctx.Request = ctx.Request.WithContext(tracectx.ContextWithSpan(ctx.Request.Context(), incoming))
+	headers := map[string]string{"X-Request-Source": "test"}
+	client := NewClient(nil)
+
+	// When
+	resp, err := client.OutgoingRequest(ctx, http.MethodGet, server.URL, nil, headers)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Then
+	outgoing, found := tracectx.Extract(received)
+	require.True(t, found)
+	assert.Equal(t, incoming.TraceID, outgoing.TraceID)
+	assert.NotEqual(t, incoming.SpanID, outgoing.SpanID, "Each hop must be a new span")
+	assert.True(t, outgoing.Sampled)
+	assert.Equal(t, incoming.TraceState, received.Get("tracestate"))
+	assert.Equal(t, incoming.TraceID, received.Get("X-Trace-ID"))
+}
+
+func TestOutgoingRequestStartsTrace(t *testing.T) {
+	// Given
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	client := NewClient(&http.Client{})
+
+	// When
+	resp, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Then
+	span, err := tracectx.ParseTraceparent(traceparent)
+	require.NoError(t, err)
+	assert.True(t, span.IsW3C())
+	assert.Empty(t, req.Header.Get("traceparent"), "The caller's request must not be modified")
+}
+
+func TestOutgoingRequestRedactsQueryString(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	origLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+	defer slog.SetDefault(origLogger)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret-value", r.URL.Query().Get("api_key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+	client := NewClient(nil)
+
+	// When
+	resp, err := client.OutgoingRequest(ctx, http.MethodGet, server.URL+"?api_key=secret-value&page=2", nil, nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Then
+	line, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(line, &entry))
+	assert.Equal(t, "api_key="+log.RedactedValue+"&page=2", entry["url.query"])
+	assert.NotContains(t, buf.String(), "secret-value")
+}
+
+func TestNewClientDoesNotModifyBaseClient(t *testing.T) {
+	// Given
+	base := &http.Client{Timeout: 5 * time.Second}
+	defaultTransport := http.DefaultClient.Transport
+
+	// When
+	client := NewClient(base)
+	NewClient(nil)
+
+	// Then
+	assert.Nil(t, base.Transport)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+	assert.NotNil(t, client.Transport)
+	assert.Equal(t, defaultTransport, http.DefaultClient.Transport)
+}
+
+func TestRequestContinuesTraceFromPlainContext(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{
+			name: "tracectx span",
+			ctx:  tracectx.ContextWithSpan(context.Background(), tracectx.SpanContext{TraceID: traceID, SpanID: "00f067aa0ba902b7", Sampled: true}),
+		},
+		{
+			name: "pkg/log trace ID",
+			ctx:  log.ContextWithTraceID(context.Background(), traceID),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var received tracectx.SpanContext
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ = tracectx.Extract(r.Header)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := NewClient(nil)
+
+			// When
+			resp, err := client.Request(tc.ctx, http.MethodGet, server.URL, nil, http.Header{"Accept": {"text/plain"}})
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			// Then
+			assert.Equal(t, traceID, received.TraceID)
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	// Given
+	server, _ := newFlakyServer(t, nil, http.StatusServiceUnavailable)
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				assert.NotEmpty(t, req.Header.Get(tracectx.TraceparentHeader), "trace headers are set first")
+				calls = append(calls, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := NewClientWithOptions(nil, Options{
+		Retry:      &RetryPolicy{InitialBackoff: time.Millisecond},
+		Middleware: []Middleware{record("first"), record("second")},
+	})
+
+	// When
+	resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// Then
+	assert.Equal(t, []string{"first", "second", "first", "second"}, calls, "middleware runs in order for every attempt")
+}
@@ -10,4 +10,12 @@ type contextKey string
 
 const (
 	ginContextKey contextKey = "gin"
+
+	// resendCountKey stores the number of times a request was sent before
+	// the current attempt
+	resendCountKey contextKey = "resend_count"
+
+	// requestSpanKey stores the span of an outgoing request created by a
+	// tracing transport wrapping the client, such as pkg/otel
+	requestSpanKey contextKey = "request_span"
 )
@@ -3,10 +3,16 @@
 // context propagation and trace ID handling.
 //
 // The package implements the following main features:
-//   - Automatic trace ID propagation across service boundaries
+//   - W3C Trace Context propagation across service boundaries
 //   - Request/Response logging with structured attributes
 //   - Integration with slog for structured logging
-//   - Compatible with Gin web framework contexts
+//   - Request, error and connection reuse metrics
+//   - Retries with exponential backoff and jitter
+//   - Per-host circuit breakers
+//   - Per-host rate and concurrency limits
+//   - Typed JSON request and response helpers
+//   - Outgoing request authentication
+//   - Compatible with Gin web framework contexts and plain contexts
 //
 // Basic usage:
 //
@@ -19,21 +25,192 @@
 //		map[string]string{"Authorization": "Bearer token"},
 //	)
 //
-// Trace ID Propagation:
-// The package automatically extracts trace IDs from incoming requests and propagates
-// them to outgoing requests through the X-Trace-ID header. The trace ID is extracted
-// in the following order:
-//  1. From Gin context stored value
-//  2. From parent context
-//  3. From request headers
+// Outside of Gin handlers, such as in background workers, Request and Do
+// take any context.Context:
+//
+//	resp, err := client.Request(ctx, http.MethodGet, "https://api.example.com", nil, http.Header{
+//		"Authorization": {"Bearer token"},
+//	})
+//
+// NewClient copies the base client rather than modifying it, so the same
+// base client, or a nil one, can be shared by several clients.
+//
+// Middleware:
+// Options.Middleware adds custom http.RoundTripper middleware. Requests go
+// through the layers of the client in the following order:
+//  1. Retries
+//  2. Options.Tracing, once per attempt
+//  3. Trace propagation and logging, once per attempt
+//  4. Options.Middleware, in order
+//  5. Rate and concurrency limits
+//  6. Circuit breakers
+//  7. Metrics
+//  8. The transport of the base client
+//
+// Options.Tracing is set by tracing libraries such as pkg/otel. The span it
+// creates is stored with ContextWithRequestSpan, so that the span ID logged
+// for the request is the parent span ID seen by the server.
+//
+// Custom middleware thus runs for every attempt, sees the trace headers, and
+// may reject requests before they count towards the circuit breakers:
+//
+//	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+//		Middleware: []ginhttp.Middleware{
+//			func(next http.RoundTripper) http.RoundTripper {
+//				return userAgentTransport{next: next}
+//			},
+//		},
+//	})
+//
+// Authentication:
+// Authentication is added as middleware, so that credentials are not passed
+// with every call:
+//   - BearerToken: A static token in the Authorization header
+//   - APIKey: A static key in a header of your choice
+//   - ClientCredentials: An OAuth2 access token from the client credentials
+//     grant, cached and fetched again ahead of its expiry or after a 401
+//     response
+//   - HMACSigner: An HMAC-SHA256 signature of the method, path and query,
+//     timestamp and body digest, verified by pkg/middlewares/signature
+//
+// For example:
+//
+//	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+//		Middleware: []ginhttp.Middleware{
+//			ginhttp.ClientCredentials(ginhttp.ClientCredentialsConfig{
+//				TokenURL:     "https://auth.example.com/oauth2/token",
+//				ClientID:     clientID,
+//				ClientSecret: clientSecret,
+//				Scopes:       []string{"billing.read"},
+//			}),
+//		},
+//	})
+//
+// Authentication runs for every attempt, so retried requests are signed
+// again and use a fresh token.
+//
+// JSON Helpers:
+// GetJSON, PostJSON, PutJSON and DoJSON encode request bodies and decode
+// responses as JSON, setting the Accept and Content-Type headers:
+//
+//	user, err := ginhttp.GetJSON[User](ginCtx, client, "https://api.example.com/users/1", nil)
+//
+//	created, err := ginhttp.PostJSON[CreateUser, User](ginCtx, client, url, req, http.Header{
+//		"Idempotency-Key": {key},
+//	})
+//
+// Non-2xx responses are returned as an *HTTPError holding the status, the
+// headers and the start of the body. When the body is a statuses.Response
+// envelope, as written by pkg/ginhttp/gin/statuses, it is decoded into
+// HTTPError.Response:
+//
+//	var httpErr *ginhttp.HTTPError
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+//		// ...
+//	}
+//
+// Response bodies larger than Options.MaxResponseBytes, 10 MiB by default,
+// fail with ErrResponseTooLarge.
+//
+// Trace Context Propagation:
+// Every outgoing request is a new span of the caller's trace, propagated with
+// the W3C traceparent and tracestate headers. The X-Trace-ID header is sent as
+// well, for services without W3C Trace Context support. The caller's span is
+// looked up in the following order:
+//  1. From the request context (see pkg/tracectx), as stored by the logger
+//     middleware
+//  2. From the Gin context stored values ("X-Trace-ID", "X-Span-ID")
+//  3. From the incoming request headers
+//  4. For plain contexts, from the IDs set with log.ContextWithTraceID and
+//     log.ContextWithSpanID
+//
+// A new trace is started when none is found. Tracing transports wrapping the
+// client, such as the one of pkg/otel, store the span they create with
+// ContextWithRequestSpan, and that span is logged and propagated instead.
 //
 // Logging:
 // All outgoing requests are automatically logged with the following attributes:
 //   - trace_id: The propagated trace ID
+//   - span_id: The span ID of the outgoing request
+//   - parent_span_id: The span ID of the caller, when known
 //   - http.request.method: The HTTP method
 //   - http.route: The request path
 //   - server.address: The target host
 //   - http.response.latency: Request duration
 //   - http.response.status_code: Response status code
+//   - url.query: Query string, when present, with sensitive parameters masked
 //   - error: Error message (if request failed)
+//
+// Metrics:
+// Outgoing requests are also recorded in the default registry of pkg/metrics,
+// which the metrics middleware serves in the Prometheus text exposition
+// format:
+//   - http_client_requests_total: Counter of requests by method, host and
+//     status class, with "error" for requests that received no response
+//   - http_client_request_duration_seconds: Histogram of request durations
+//   - http_client_errors_total: Counter of failures by class: timeout, dns,
+//     connection_refused, canceled, 5xx or other
+//   - http_client_connections_total: Counter of connections obtained, by
+//     whether they were reused from the idle pool
+//
+// Retries:
+// Clients created with a RetryPolicy retry requests that failed with a
+// network error or a 429, 502, 503 or 504 response:
+//
+//	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+//		Retry: &ginhttp.RetryPolicy{MaxAttempts: 4},
+//	})
+//
+// The wait before each retry is random, up to a ceiling that doubles from
+// InitialBackoff to MaxBackoff, unless the response has a Retry-After header.
+// A Retry-After longer than MaxBackoff ends the retries. Only idempotent
+// methods and requests with an Idempotency-Key header are retried, unless
+// RetryNonIdempotent is set, and request bodies must be replayable through
+// Request.GetBody, as set by http.NewRequest for in-memory bodies.
+//
+// Every attempt is logged and counted in the metrics under the trace of the
+// original request, with the http.request.resend_count attribute on retries.
+// Each retry is also logged as a warning with its delay and cause.
+//
+// Circuit Breakers:
+// Clients created with a CircuitBreakerPolicy stop sending requests to hosts
+// that keep failing, instead of waiting for each request to time out:
+//
+//	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+//		CircuitBreaker: &ginhttp.CircuitBreakerPolicy{ConsecutiveFailures: 5},
+//	})
+//
+// Each host has a circuit that opens after ConsecutiveFailures failures in a
+// row, or when FailureRatio of the requests of the current Window failed.
+// Network errors and 5xx responses are failures. An open circuit rejects
+// requests with a *CircuitOpenError, matching ErrCircuitOpen, which is not
+// retried. After OpenTimeout, the circuit is half-open and lets probe
+// requests through, closing if they succeed and opening again otherwise.
+//
+// State changes are logged with the trace ID of the request that caused them,
+// and CircuitStates reports the state of every host for health endpoints:
+//
+//	router.GET("/health", func(c *gin.Context) {
+//		c.JSON(http.StatusOK, gin.H{"downstreams": client.CircuitStates()})
+//	})
+//
+// Rate Limits:
+// Clients created with a RateLimitPolicy delay requests to hosts with strict
+// quotas, instead of sending bursts that get 429 responses:
+//
+//	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+//		RateLimit: &ginhttp.RateLimitPolicy{Rate: 10, Burst: 5, MaxInFlight: 4, Adaptive: true},
+//	})
+//
+// Each host, or each key returned by RateLimitPolicy.Key, has a token bucket
+// allowing Rate requests per second with bursts of Burst requests, and at
+// most MaxInFlight requests in progress until their response body is closed.
+// Requests over the limits wait until their context is done. With Adaptive,
+// a 429 response makes the requests to its host wait for its Retry-After
+// header and halves the rate, which then recovers with the next responses.
+//
+// Delayed requests are logged with their trace ID, delay and reason ("rate",
+// "retry_after" or "concurrency"), and recorded in the
+// http_client_throttled_requests_total counter and the
+// http_client_throttle_wait_seconds histogram, labeled by key and reason.
 package ginhttp
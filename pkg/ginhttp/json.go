@@ -0,0 +1,189 @@
+package ginhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	statuses "github.com/CloudLearnersOrg/golib/pkg/ginhttp/gin/statuses"
+)
+
+// defaultMaxResponseBytes is the default of Options.MaxResponseBytes
+const defaultMaxResponseBytes = 10 << 20
+
+// maxErrorBodyBytes bounds the body kept by HTTPError
+const maxErrorBodyBytes = 4096
+
+// ErrResponseTooLarge is returned by the JSON helpers for response bodies
+// larger than Options.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("response body is too large")
+
+// HTTPError is returned by the JSON helpers for non-2xx responses
+type HTTPError struct {
+	// StatusCode is the status of the response
+	StatusCode int
+
+	// Header holds the response headers
+	Header http.Header
+
+	// Body is the start of the response body, up to 4 KiB
+	Body []byte
+
+	// Truncated reports whether Body is shorter than the response body
+	Truncated bool
+
+	// Response is the decoded body when it is a golib statuses.Response
+	// envelope, and nil otherwise
+	Response *statuses.Response
+}
+
+// Error returns the status and, when the body is a statuses.Response
+// envelope, its message and error
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Response == nil {
+		return msg
+	}
+
+	if e.Response.Message != "" {
+		msg += ": " + e.Response.Message
+	}
+	if e.Response.Error != "" {
+		msg += ": " + e.Response.Error
+	}
+	return msg
+}
+
+// GetJSON sends a GET request and decodes the JSON response into a T
+func GetJSON[T any](ctx context.Context, c *Client, url string, header http.Header) (T, error) {
+	return doJSON[T](ctx, c, http.MethodGet, url, nil, header)
+}
+
+// PostJSON sends body encoded as JSON in a POST request and decodes the JSON
+// response into a Resp
+func PostJSON[Req, Resp any](ctx context.Context, c *Client, url string, body Req, header http.Header) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, c, http.MethodPost, url, body, header)
+}
+
+// PutJSON sends body encoded as JSON in a PUT request and decodes the JSON
+// response into a Resp
+func PutJSON[Req, Resp any](ctx context.Context, c *Client, url string, body Req, header http.Header) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, c, http.MethodPut, url, body, header)
+}
+
+// DoJSON sends body encoded as JSON with the given method and decodes the
+// JSON response into a Resp. Non-2xx responses are returned as an
+// *HTTPError, and empty responses, such as 204 No Content, leave the
+// zero Resp.
+//
+// The trace found in ctx is propagated like with Do.
+func DoJSON[Req, Resp any](ctx context.Context, c *Client, method, url string, body Req, header http.Header) (Resp, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		var zero Resp
+		return zero, fmt.Errorf("encoding request body: %w", err)
+	}
+	return doJSON[Resp](ctx, c, method, url, encoded, header)
+}
+
+// doJSON sends the encoded body, if any, and decodes the response into a T
+func doJSON[T any](ctx context.Context, c *Client, method, url string, body []byte, header http.Header) (T, error) {
+	var result T
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if header.Get("Accept") == "" {
+		header.Set("Accept", "application/json")
+	}
+	if body != nil && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Request(ctx, method, url, reader, header)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, newHTTPError(resp)
+	}
+
+	data, err := readLimited(resp.Body, c.maxResponseBytes())
+	if err != nil || len(data) == 0 {
+		return result, err
+	}
+
+	if !isJSON(resp.Header.Get("Content-Type")) {
+		return result, fmt.Errorf("unexpected response content type %q", resp.Header.Get("Content-Type"))
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("decoding response body: %w", err)
+	}
+	return result, nil
+}
+
+// maxResponseBytes returns the size limit of response bodies
+func (c *Client) maxResponseBytes() int64 {
+	if c.maxResponse <= 0 {
+		return defaultMaxResponseBytes
+	}
+	return c.maxResponse
+}
+
+// readLimited reads r, failing if it is longer than limit
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, limit)
+	}
+	return data, nil
+}
+
+// newHTTPError reads the start of the body of a non-2xx response
+func newHTTPError(resp *http.Response) *HTTPError {
+	httpErr := &HTTPError{StatusCode: resp.StatusCode, Header: resp.Header}
+
+	// A read error leaves the body read so far
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes+1))
+	if len(body) > maxErrorBodyBytes {
+		body, httpErr.Truncated = body[:maxErrorBodyBytes], true
+	}
+	httpErr.Body = body
+
+	if !httpErr.Truncated && isJSON(resp.Header.Get("Content-Type")) {
+		var envelope statuses.Response
+		if json.Unmarshal(body, &envelope) == nil && envelope.Code != 0 &&
+			(envelope.Message != "" || envelope.Error != "") {
+			httpErr.Response = &envelope
+		}
+	}
+
+	return httpErr
+}
+
+// isJSON reports whether the content type is JSON, such as application/json
+// or application/problem+json
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
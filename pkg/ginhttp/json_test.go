@@ -0,0 +1,181 @@
+package ginhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	statuses "github.com/CloudLearnersOrg/golib/pkg/ginhttp/gin/statuses"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetJSON(t *testing.T) {
+	// Given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		assert.Equal(t, "secret", r.Header.Get("X-API-Key"))
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, err := w.Write([]byte(`{"id":1,"name":"Ada"}`))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{})
+
+	// When
+	got, err := GetJSON[user](context.Background(), client, server.URL, http.Header{"X-Api-Key": {"secret"}})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, user{ID: 1, Name: "Ada"}, got)
+}
+
+func TestPostJSON(t *testing.T) {
+	// Given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var created user
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		created.ID = 42
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, json.NewEncoder(w).Encode(created))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{})
+
+	// When
+	got, err := PostJSON[user, user](context.Background(), client, server.URL, user{Name: "Ada"}, nil)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, user{ID: 42, Name: "Ada"}, got)
+}
+
+func TestJSONPropagatesGinTrace(t *testing.T) {
+	// Given
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(tracectx.TraceparentHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+	ctx.Request.Header.Set(tracectx.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	client := NewClient(&http.Client{})
+
+	// When
+	got, err := GetJSON[*user](ctx, client, server.URL, nil)
+
+	// Then
+	require.NoError(t, err)
+	assert.Nil(t, got, "empty responses leave the zero value")
+	assert.True(t, strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+}
+
+func TestJSONErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		check       func(t *testing.T, err error)
+	}{
+		{
+			name:        "golib envelope",
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			body:        `{"code":404,"message":"User not found","error":"no user with id 1"}`,
+			check: func(t *testing.T, err error) {
+				var httpErr *HTTPError
+				require.ErrorAs(t, err, &httpErr)
+				assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+				assert.Equal(t, "application/json", httpErr.Header.Get("Content-Type"))
+				assert.Equal(t, &statuses.Response{Code: 404, Message: "User not found", Error: "no user with id 1"}, httpErr.Response)
+				assert.EqualError(t, err, "unexpected status 404 Not Found: User not found: no user with id 1")
+			},
+		},
+		{
+			name:        "other body",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        strings.Repeat("x", maxErrorBodyBytes+1),
+			check: func(t *testing.T, err error) {
+				var httpErr *HTTPError
+				require.ErrorAs(t, err, &httpErr)
+				assert.Nil(t, httpErr.Response)
+				assert.True(t, httpErr.Truncated)
+				assert.Len(t, httpErr.Body, maxErrorBodyBytes)
+				assert.EqualError(t, err, "unexpected status 502 Bad Gateway")
+			},
+		},
+		{
+			name:        "too large",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id":1,"name":"` + strings.Repeat("a", 100) + `"}`,
+			check: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrResponseTooLarge)
+			},
+		},
+		{
+			name:        "not json",
+			status:      http.StatusOK,
+			contentType: "text/plain",
+			body:        "ok",
+			check: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, `unexpected response content type "text/plain"`)
+			},
+		},
+		{
+			name:        "invalid json",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id":`,
+			check: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "decoding response body")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.status)
+				_, err := io.WriteString(w, tc.body)
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client := NewClientWithOptions(&http.Client{}, Options{MaxResponseBytes: 64})
+
+			// When
+			_, err := GetJSON[user](context.Background(), client, server.URL, nil)
+
+			// Then
+			tc.check(t, err)
+		})
+	}
+}
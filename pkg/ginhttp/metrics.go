@@ -0,0 +1,105 @@
+package ginhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+)
+
+// Error classes of the http_client_errors_total metric
+const (
+	errorClassTimeout           = "timeout"
+	errorClassDNS               = "dns"
+	errorClassConnectionRefused = "connection_refused"
+	errorClassCanceled          = "canceled"
+	errorClassServerError       = "5xx"
+	errorClassOther             = "other"
+)
+
+// clientMetrics are the metrics recorded for outgoing requests
+type clientMetrics struct {
+	requests    *metrics.CounterVec
+	duration    *metrics.HistogramVec
+	errors      *metrics.CounterVec
+	connections *metrics.CounterVec
+}
+
+// newClientMetrics registers the client metrics in the registry, which is
+// shared with the metrics middleware
+func newClientMetrics(r *metrics.Registry) clientMetrics {
+	return clientMetrics{
+		requests: r.Counter("http_client_requests_total",
+			"Number of outgoing HTTP requests.", "method", "host", "status_class"),
+		duration: r.Histogram("http_client_request_duration_seconds",
+			"Duration of outgoing HTTP requests in seconds.", metrics.DefaultLatencyBuckets, "method", "host", "status_class"),
+		errors: r.Counter("http_client_errors_total",
+			"Number of failed outgoing HTTP requests by error class.", "method", "host", "class"),
+		connections: r.Counter("http_client_connections_total",
+			"Number of connections obtained for outgoing HTTP requests, by whether they were reused.", "host", "reused"),
+	}
+}
+
+// newMetricsRoundTripper creates a new round tripper that records metrics of
+// outgoing requests
+func newMetricsRoundTripper(next http.RoundTripper, registry *metrics.Registry) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	m := newClientMetrics(registry)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		method, host := req.Method, req.URL.Host
+
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				m.connections.Inc(host, strconv.FormatBool(info.Reused))
+			},
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		duration := time.Since(start).Seconds()
+
+		if err != nil {
+			m.requests.Inc(method, host, "error")
+			m.duration.Observe(duration, method, host, "error")
+			m.errors.Inc(method, host, errorClass(err))
+			return resp, err
+		}
+
+		status := metrics.StatusClass(resp.StatusCode)
+		m.requests.Inc(method, host, status)
+		m.duration.Observe(duration, method, host, status)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			m.errors.Inc(method, host, errorClassServerError)
+		}
+		return resp, nil
+	})
+}
+
+// errorClass classifies a transport error
+func errorClass(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return errorClassDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return errorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return errorClassConnectionRefused
+	case errors.Is(err, context.Canceled):
+		return errorClassCanceled
+	default:
+		return errorClassOther
+	}
+}
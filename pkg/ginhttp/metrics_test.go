@@ -0,0 +1,153 @@
+package ginhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRecordsMetrics(t *testing.T) {
+	// Given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+	m := newClientMetrics(metrics.Default())
+	client := NewClient(&http.Client{})
+
+	// When
+	for _, path := range []string{"/ok", "/ok", "/fail"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	// Then
+	assert.Equal(t, float64(2), m.requests.Value(http.MethodGet, host, "2xx"))
+	assert.Equal(t, float64(1), m.requests.Value(http.MethodGet, host, "5xx"))
+	assert.Equal(t, float64(1), m.errors.Value(http.MethodGet, host, errorClassServerError))
+
+	count, _ := m.duration.Count(http.MethodGet, host, "2xx")
+	assert.Equal(t, uint64(2), count)
+
+	// The first request opens the connection, which later requests reuse
+	assert.Equal(t, float64(1), m.connections.Value(host, "false"))
+	assert.Equal(t, float64(2), m.connections.Value(host, "true"))
+}
+
+func TestClientRecordsTransportErrors(t *testing.T) {
+	t.Run("connection refused", func(t *testing.T) {
+		// Given
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		host := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		m := newClientMetrics(metrics.Default())
+		client := NewClient(&http.Client{})
+
+		// When
+		_, err = client.Get("http://" + host)
+
+		// Then
+		require.Error(t, err)
+		assert.Equal(t, float64(1), m.errors.Value(http.MethodGet, host, errorClassConnectionRefused))
+		assert.Equal(t, float64(1), m.requests.Value(http.MethodGet, host, "error"))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		// Given
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		host := server.Listener.Addr().String()
+		m := newClientMetrics(metrics.Default())
+		client := NewClient(&http.Client{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		// When
+		_, err = client.Do(ctx, req)
+
+		// Then
+		require.Error(t, err)
+		assert.Equal(t, float64(1), m.errors.Value(http.MethodGet, host, errorClassTimeout))
+	})
+}
+
+func TestErrorClass(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "DNS",
+			err:      &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "api.invalid"}},
+			expected: errorClassDNS,
+		},
+		{
+			name:     "DNS timeout",
+			err:      &net.DNSError{Err: "i/o timeout", IsTimeout: true},
+			expected: errorClassDNS,
+		},
+		{
+			name:     "deadline",
+			err:      context.DeadlineExceeded,
+			expected: errorClassTimeout,
+		},
+		{
+			name:     "network timeout",
+			err:      &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded},
+			expected: errorClassTimeout,
+		},
+		{
+			name:     "connection refused",
+			err:      &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			expected: errorClassConnectionRefused,
+		},
+		{
+			name:     "canceled",
+			err:      context.Canceled,
+			expected: errorClassCanceled,
+		},
+		{
+			name:     "other",
+			err:      errors.New("tls: handshake failure"),
+			expected: errorClassOther,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			class := errorClass(tc.err)
+
+			// Then
+			assert.Equal(t, tc.expected, class)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package ginhttp
+
+import "net/http"
+
+// Middleware wraps the transport of a Client, to observe or modify outgoing
+// requests. A RoundTripper must not modify the request it receives, so
+// middleware setting headers must do so on a clone.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Options configures the optional behaviors of a Client
+type Options struct {
+	// Retry, when set, retries failed requests according to the policy.
+	// Example: &ginhttp.RetryPolicy{MaxAttempts: 3}
+	Retry *RetryPolicy
+
+	// CircuitBreaker, when set, rejects requests to hosts that keep
+	// failing, with a CircuitOpenError, until they recover.
+	// Example: &ginhttp.CircuitBreakerPolicy{ConsecutiveFailures: 5}
+	CircuitBreaker *CircuitBreakerPolicy
+
+	// RateLimit, when set, delays requests over per-host rate and
+	// concurrency limits.
+	// Example: &ginhttp.RateLimitPolicy{Rate: 10, MaxInFlight: 4}
+	RateLimit *RateLimitPolicy
+
+	// MaxResponseBytes limits the size of the response bodies decoded by
+	// the JSON helpers, such as GetJSON. Defaults to 10 MiB.
+	MaxResponseBytes int64
+
+	// Tracing wraps the logging of every attempt, for tracing libraries
+	// creating a span per request, such as pkg/otel. The span it stores in
+	// the request context with ContextWithRequestSpan is logged and
+	// propagated to the server instead of a new child span.
+	Tracing Middleware
+
+	// Middleware wraps the transport, the first middleware receiving the
+	// requests first. It runs for every attempt, after the trace headers
+	// are set and before the rate limits, circuit breakers and metrics.
+	Middleware []Middleware
+}
@@ -0,0 +1,300 @@
+package ginhttp
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+)
+
+// Reasons for delaying a request, as logged and recorded in the metrics
+const (
+	throttleReasonRate        = "rate"
+	throttleReasonRetryAfter  = "retry_after"
+	throttleReasonConcurrency = "concurrency"
+)
+
+// Adaptive slowdown of RateLimitPolicy: the rate is halved on every 429
+// response, down to 1/16 of the configured rate, and recovers by 1/20 of the
+// configured rate with every other response
+const (
+	adaptiveDecrease = 0.5
+	adaptiveMinRatio = 1.0 / 16
+	adaptiveIncrease = 1.0 / 20
+)
+
+// RateLimitPolicy configures client-side limits of the requests sent to each
+// host, or to each key returned by Key. Requests over the limits wait, until
+// their context is done.
+type RateLimitPolicy struct {
+	// Rate is the number of requests per second allowed by a token bucket.
+	// Zero disables rate limiting.
+	Rate float64
+
+	// Burst is the number of requests that can be sent at once when the
+	// bucket is full. Defaults to Rate rounded up, and at least 1.
+	Burst int
+
+	// MaxInFlight limits the requests in progress, until their response
+	// body is closed. Zero disables concurrency limiting.
+	MaxInFlight int
+
+	// Adaptive slows requests down when 429 responses arrive: requests wait
+	// for the Retry-After header of the response, if any, and the rate is
+	// halved, then recovers gradually with the next responses.
+	Adaptive bool
+
+	// Key returns the key whose limits apply to the request. Defaults to
+	// the host of the request. Keys are metric labels, so they must come
+	// from a small set.
+	// Example: func(req *http.Request) string { return req.Header.Get("X-Tenant") }
+	Key func(req *http.Request) string
+}
+
+// hostKey is the default RateLimitPolicy.Key
+func hostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// throttleMetrics are the metrics recorded for delayed requests
+type throttleMetrics struct {
+	delayed *metrics.CounterVec
+	wait    *metrics.HistogramVec
+}
+
+// newThrottleMetrics registers the throttling metrics in the registry
+func newThrottleMetrics(r *metrics.Registry) throttleMetrics {
+	return throttleMetrics{
+		delayed: r.Counter("http_client_throttled_requests_total",
+			"Number of outgoing HTTP requests delayed by client-side limits.", "key", "reason"),
+		wait: r.Histogram("http_client_throttle_wait_seconds",
+			"Time outgoing HTTP requests waited for client-side limits in seconds.", metrics.DefaultLatencyBuckets, "key", "reason"),
+	}
+}
+
+// bucket holds the limits of a key
+type bucket struct {
+	rate        float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	inFlight    chan struct{}
+}
+
+// rateLimiter holds the limits of a client by key
+type rateLimiter struct {
+	policy  RateLimitPolicy
+	metrics throttleMetrics
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// newRateLimiter creates the limits of a client
+func newRateLimiter(policy RateLimitPolicy, registry *metrics.Registry) *rateLimiter {
+	if policy.Burst <= 0 {
+		policy.Burst = max(1, int(math.Ceil(policy.Rate)))
+	}
+	if policy.Key == nil {
+		policy.Key = hostKey
+	}
+
+	return &rateLimiter{
+		policy:  policy,
+		metrics: newThrottleMetrics(registry),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// bucket returns the bucket of key, creating a full one on first use. The
+// caller must hold the lock.
+func (l *rateLimiter) bucket(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{rate: l.policy.Rate, tokens: float64(l.policy.Burst), last: now}
+		if l.policy.MaxInFlight > 0 {
+			b.inFlight = make(chan struct{}, l.policy.MaxInFlight)
+		}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// reserve takes a token from the bucket of key, returning how long to wait
+// before sending the request and why
+func (l *rateLimiter) reserve(key string) (time.Duration, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.bucket(key, now)
+
+	var wait time.Duration
+	reason := throttleReasonRate
+	if paused := b.pausedUntil.Sub(now); paused > 0 {
+		wait, reason = paused, throttleReasonRetryAfter
+	}
+
+	if b.rate > 0 {
+		b.tokens = min(float64(l.policy.Burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		// Tokens go negative as requests queue up, each one waiting for
+		// the tokens taken before it to be refilled
+		b.tokens--
+		if b.tokens < 0 {
+			if refill := time.Duration(-b.tokens / b.rate * float64(time.Second)); refill > wait {
+				wait, reason = refill, throttleReasonRate
+			}
+		}
+	}
+
+	return wait, reason
+}
+
+// cancel returns the token of a request that gave up waiting
+func (l *rateLimiter) cancel(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b := l.buckets[key]; b.rate > 0 {
+		b.tokens++
+	}
+}
+
+// observe adapts the rate of key to the response
+func (l *rateLimiter) observe(key string, resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.bucket(key, now)
+	base := l.policy.Rate
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if b.rate < base {
+			b.rate = min(base, b.rate+base*adaptiveIncrease)
+		}
+		return
+	}
+
+	if retryAfter, ok := parseRetryAfter(resp, now); ok {
+		b.pausedUntil = maxTime(b.pausedUntil, now.Add(retryAfter))
+	}
+	if base > 0 {
+		b.rate = max(b.rate*adaptiveDecrease, base*adaptiveMinRatio)
+	}
+}
+
+// wait blocks until the request may be sent, returning a function releasing
+// its in-flight slot
+func (l *rateLimiter) wait(req *http.Request, key string) (func(), error) {
+	ctx := req.Context()
+
+	if delay, reason := l.reserve(key); delay > 0 {
+		l.delayed(req, key, reason, delay)
+		if err := sleep(ctx, delay); err != nil {
+			l.cancel(key)
+			return nil, err
+		}
+	}
+
+	l.mu.Lock()
+	inFlight := l.buckets[key].inFlight
+	l.mu.Unlock()
+	if inFlight == nil {
+		return func() {}, nil
+	}
+
+	release := func() { <-inFlight }
+	select {
+	case inFlight <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	start := l.now()
+	select {
+	case inFlight <- struct{}{}:
+		l.delayed(req, key, throttleReasonConcurrency, l.now().Sub(start))
+		return release, nil
+	case <-ctx.Done():
+		l.cancel(key)
+		return nil, ctx.Err()
+	}
+}
+
+// delayed records and logs a request delayed by the limits
+func (l *rateLimiter) delayed(req *http.Request, key, reason string, delay time.Duration) {
+	l.metrics.delayed.Inc(key, reason)
+	l.metrics.wait.Observe(delay.Seconds(), key, reason)
+
+	slog.InfoContext(req.Context(), "outgoing request delayed",
+		"trace_id", extractSpan(req.Context()).TraceID,
+		"http.request.method", req.Method,
+		"server.address", req.URL.Host,
+		"throttle.key", key,
+		"throttle.reason", reason,
+		"throttle.delay", delay.String(),
+	)
+}
+
+// newRateLimitRoundTripper creates a new round tripper that delays requests
+// over the limits of the policy
+func newRateLimitRoundTripper(next http.RoundTripper, limiter *rateLimiter) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		key := limiter.policy.Key(req)
+
+		release, err := limiter.wait(req, key)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		if limiter.policy.Adaptive {
+			limiter.observe(key, resp)
+		}
+
+		if limiter.policy.MaxInFlight <= 0 {
+			return resp, nil
+		}
+
+		// The request is in flight until its body is closed
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+// releasingBody releases the in-flight slot of a request when closed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close closes the body and releases the slot
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
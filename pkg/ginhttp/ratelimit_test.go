@@ -0,0 +1,162 @@
+package ginhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter returns a rate limiter driven by a fake clock
+func newTestLimiter(policy RateLimitPolicy) (*rateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := newRateLimiter(policy, metrics.NewRegistry())
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestRateLimitDelaysBursts(t *testing.T) {
+	// Given
+	limiter, clock := newTestLimiter(RateLimitPolicy{Rate: 10, Burst: 2})
+
+	// When
+	var waits []time.Duration
+	for range 4 {
+		wait, _ := limiter.reserve("api")
+		waits = append(waits, wait)
+	}
+	clock.now = clock.now.Add(time.Second)
+	afterRefill, _ := limiter.reserve("api")
+
+	// Then
+	assert.Equal(t, []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond}, waits)
+	assert.Zero(t, afterRefill)
+}
+
+func TestRateLimitAdaptsToTooManyRequests(t *testing.T) {
+	// Given
+	limiter, clock := newTestLimiter(RateLimitPolicy{Rate: 10, Burst: 1, Adaptive: true})
+	_, _ = limiter.reserve("api")
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"2"}}}
+
+	// When
+	limiter.observe("api", resp)
+	wait, reason := limiter.reserve("api")
+
+	// Then
+	assert.Equal(t, 2*time.Second, wait)
+	assert.Equal(t, throttleReasonRetryAfter, reason)
+	assert.Equal(t, 5.0, limiter.buckets["api"].rate, "the rate is halved")
+
+	// When the host recovers
+	clock.now = clock.now.Add(time.Minute)
+	for range 20 {
+		limiter.observe("api", &http.Response{StatusCode: http.StatusOK})
+	}
+
+	// Then
+	assert.Equal(t, 10.0, limiter.buckets["api"].rate, "the rate recovers up to the configured rate")
+}
+
+func TestRateLimitWaitHonorsContext(t *testing.T) {
+	// Given
+	server, attempts := newFlakyServer(t, nil)
+	client := NewClientWithOptions(nil, Options{RateLimit: &RateLimitPolicy{Rate: 0.1}})
+
+	resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	body := &trackingBody{Reader: strings.NewReader(`{"id":42}`)}
+
+	// When
+	start := time.Now()
+	_, err = client.Request(ctx, http.MethodPost, server.URL, body, nil)
+
+	// Then
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), attempts.Load(), "the delayed request is not sent")
+	assert.True(t, body.closed, "the body of the unsent request is closed")
+}
+
+func TestRateLimitReturnsTokenWhenConcurrencyWaitIsCanceled(t *testing.T) {
+	// Given
+	limiter, _ := newTestLimiter(RateLimitPolicy{Rate: 10, Burst: 2, MaxInFlight: 1})
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com", nil)
+	release, err := limiter.wait(req, "api")
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// When
+	_, err = limiter.wait(req.WithContext(ctx), "api")
+
+	// Then
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1.0, limiter.buckets["api"].tokens, "the token of the canceled request is returned")
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	received, unblock := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(received)
+			<-unblock
+		}
+	}))
+	defer server.Close()
+
+	key := func(*http.Request) string { return "partner" }
+	client := NewClientWithOptions(nil, Options{RateLimit: &RateLimitPolicy{MaxInFlight: 1, Key: key}})
+	delayed := newThrottleMetrics(metrics.Default()).delayed
+	before := delayed.Value("partner", throttleReasonConcurrency)
+
+	slow := make(chan *http.Response)
+	go func() {
+		resp, err := client.Request(context.Background(), http.MethodGet, server.URL+"/slow", nil, nil)
+		assert.NoError(t, err)
+		slow <- resp
+	}()
+	<-received
+
+	// When
+	fast := make(chan error)
+	go func() {
+		resp, err := client.Request(context.Background(), http.MethodGet, server.URL+"/fast", nil, nil)
+		if err == nil {
+			err = resp.Body.Close()
+		}
+		fast <- err
+	}()
+
+	close(unblock)
+	slowResp := <-slow
+	select {
+	case <-fast:
+		t.Fatal("the fast request must wait until the slow response body is closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, slowResp.Body.Close())
+
+	// Then
+	require.NoError(t, <-fast)
+	assert.Equal(t, before+1, delayed.Value("partner", throttleReasonConcurrency))
+	rec.AssertContains("INFO", "outgoing request delayed", map[string]any{
+		"throttle.key":    "partner",
+		"throttle.reason": throttleReasonConcurrency,
+	})
+}
@@ -0,0 +1,239 @@
+package ginhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+)
+
+// Defaults of RetryPolicy
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+)
+
+// maxDrainBytes bounds how much of a discarded response body is read so that
+// its connection can be reused
+const maxDrainBytes = 4096
+
+// defaultRetryStatuses are the response statuses retried by default
+var defaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// idempotentMethods are the methods retried without RetryNonIdempotent
+var idempotentMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+	http.MethodPut, http.MethodDelete,
+}
+
+// RetryPolicy configures the retries of failed requests. Requests are retried
+// on network errors and on the RetryStatuses, waiting with exponential
+// backoff and full jitter between attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first one.
+	// Defaults to 3.
+	MaxAttempts int
+
+	// InitialBackoff is the upper bound of the wait before the first
+	// retry, doubled for every further retry. Defaults to 100ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts. A Retry-After header
+	// asking for a longer wait ends the retries. Defaults to 10s.
+	MaxBackoff time.Duration
+
+	// RetryStatuses are the response statuses that are retried.
+	// Defaults to 429, 502, 503 and 504.
+	RetryStatuses []int
+
+	// RetryNonIdempotent retries POST, PATCH and other non-idempotent
+	// requests. By default, they are only retried when they carry an
+	// Idempotency-Key header.
+	RetryNonIdempotent bool
+}
+
+// withDefaults returns the policy with unset fields defaulted
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if len(p.RetryStatuses) == 0 {
+		p.RetryStatuses = defaultRetryStatuses
+	}
+	return p
+}
+
+// retryable reports whether the request may be sent again
+func (p RetryPolicy) retryable(req *http.Request) bool {
+	// Bodies can only be sent again if they can be recreated
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	return p.RetryNonIdempotent ||
+		slices.Contains(idempotentMethods, req.Method) ||
+		req.Header.Get("Idempotency-Key") != ""
+}
+
+// backoff returns a random wait of up to InitialBackoff doubled for each
+// previous retry, capped at MaxBackoff
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.MaxBackoff
+	if shift := uint(retry); shift < 32 {
+		ceiling = min(p.InitialBackoff<<shift, p.MaxBackoff)
+	}
+	return rand.N(ceiling + 1)
+}
+
+// newRetryRoundTripper creates a new round tripper that retries failed
+// requests according to the policy
+func newRetryRoundTripper(next http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	policy = policy.withDefaults()
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		retryable := policy.retryable(req)
+
+		// Every attempt is logged under the same trace, which is started
+		// here when the caller has none
+		if retryable && extractSpan(ctx).TraceID == "" {
+			ctx = tracectx.ContextWithSpan(ctx, tracectx.New())
+			req = req.WithContext(ctx)
+		}
+		attemptReq := req
+
+		for attempt := 1; ; attempt++ {
+			resp, err := next.RoundTrip(attemptReq)
+			if !retryable || attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.shouldRetry(resp, err) {
+				return resp, err
+			}
+
+			delay := policy.backoff(attempt - 1)
+			if retryAfter, ok := parseRetryAfter(resp, time.Now()); ok {
+				if retryAfter > policy.MaxBackoff {
+					return resp, err
+				}
+				delay = retryAfter
+			}
+
+			logRetry(req, attempt, delay, resp, err)
+			discard(resp)
+
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+
+			if attemptReq, err = retryRequest(req, attempt); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+// shouldRetry reports whether the outcome of an attempt is retried
+func (p RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrCircuitOpen)
+	}
+	return slices.Contains(p.RetryStatuses, resp.StatusCode)
+}
+
+// retryRequest returns a copy of req for the next attempt, with its body
+// recreated and the resend count in its context
+func retryRequest(req *http.Request, attempt int) (*http.Request, error) {
+	retry := req.Clone(context.WithValue(req.Context(), resendCountKey, attempt))
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header, given
+// in seconds or as an HTTP date
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// discard drains and closes the body of a response that is not returned, so
+// that its connection can be reused
+func discard(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	_ = resp.Body.Close()
+}
+
+// sleep waits for the delay or until the context is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// logRetry logs a retried attempt with the trace ID of the request
+func logRetry(req *http.Request, attempt int, delay time.Duration, resp *http.Response, err error) {
+	attrs := []any{
+		"trace_id", extractSpan(req.Context()).TraceID,
+		"http.request.method", req.Method,
+		"server.address", req.URL.Host,
+		"http.request.resend_count", attempt,
+		"retry.delay", delay.String(),
+	}
+
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	} else {
+		attrs = append(attrs, "http.response.status_code", resp.StatusCode)
+	}
+
+	slog.WarnContext(req.Context(), "retrying outgoing request", attrs...)
+}
@@ -0,0 +1,295 @@
+package ginhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer returns a server answering with the statuses in order, then
+// with 200 OK, and a counter of the requests it received
+func newFlakyServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(attempts.Add(1))
+		if n > len(statuses) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(statuses[n-1])
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &attempts
+}
+
+func TestRetryFailedRequests(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	server, attempts := newFlakyServer(t, nil, http.StatusServiceUnavailable, http.StatusBadGateway)
+	client := NewClientWithOptions(&http.Client{}, Options{
+		Retry: &RetryPolicy{InitialBackoff: time.Millisecond},
+	})
+
+	// When
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// Then
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	// Every attempt is logged under the same trace
+	var traceIDs []any
+	for _, entry := range rec.Entries() {
+		if strings.HasPrefix(entry.Message, "outgoing request") {
+			traceIDs = append(traceIDs, entry.Fields["trace_id"])
+		}
+	}
+	require.Len(t, traceIDs, 3)
+	assert.NotEmpty(t, traceIDs[0])
+	assert.Equal(t, traceIDs[0], traceIDs[1])
+	assert.Equal(t, traceIDs[0], traceIDs[2])
+
+	rec.AssertContains("WARN", "retrying outgoing request", map[string]any{
+		"trace_id":                  traceIDs[0],
+		"http.request.resend_count": int64(1),
+		"http.response.status_code": int64(http.StatusServiceUnavailable),
+	})
+	rec.AssertContains("INFO", "outgoing request completed", map[string]any{
+		"http.request.resend_count": int64(2),
+		"http.response.status_code": int64(http.StatusOK),
+	})
+}
+
+func TestRetryStopsAfterMaxAttempts(t *testing.T) {
+	// Given
+	server, attempts := newFlakyServer(t, nil,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	client := NewClientWithOptions(&http.Client{}, Options{
+		Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+
+	// When
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// Then
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name             string
+		retryAfter       string
+		expectedStatus   int
+		expectedAttempts int32
+	}{
+		{
+			name:             "wait within the maximum backoff",
+			retryAfter:       "0",
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "wait beyond the maximum backoff",
+			retryAfter:       "120",
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			header := http.Header{"Retry-After": {tc.retryAfter}}
+			server, attempts := newFlakyServer(t, header, http.StatusTooManyRequests)
+			client := NewClientWithOptions(&http.Client{}, Options{
+				Retry: &RetryPolicy{InitialBackoff: time.Hour, MaxBackoff: time.Minute},
+			})
+
+			// When
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			// Then
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tc.expectedAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestRetryNonIdempotentRequests(t *testing.T) {
+	testCases := []struct {
+		name             string
+		policy           RetryPolicy
+		idempotencyKey   string
+		expectedAttempts int32
+	}{
+		{
+			name:             "not retried by default",
+			expectedAttempts: 1,
+		},
+		{
+			name:             "retried with an idempotency key",
+			idempotencyKey:   "order-42",
+			expectedAttempts: 2,
+		},
+		{
+			name:             "retried when opted in",
+			policy:           RetryPolicy{RetryNonIdempotent: true},
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, `{"id":42}`, string(body), "the body is sent with every attempt")
+
+				if attempts.Add(1) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			tc.policy.InitialBackoff = time.Millisecond
+			client := NewClientWithOptions(&http.Client{}, Options{Retry: &tc.policy})
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"id":42}`))
+			require.NoError(t, err)
+			if tc.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", tc.idempotencyKey)
+			}
+
+			// When
+			resp, err := client.Do(context.Background(), req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			// Then
+			assert.Equal(t, tc.expectedAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	// Given
+	var attempts int
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	transport := newRetryRoundTripper(next, RetryPolicy{InitialBackoff: time.Millisecond})
+
+	// When
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	// Given
+	header := http.Header{"Retry-After": {"5"}}
+	server, attempts := newFlakyServer(t, header, http.StatusServiceUnavailable)
+	client := NewClientWithOptions(&http.Client{}, Options{Retry: &RetryPolicy{}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	// When
+	start := time.Now()
+	_, err = client.Do(ctx, req)
+
+	// Then
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second, "the backoff is interrupted")
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	// Given
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefaults()
+
+	for retry, ceiling := range []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond,
+		800 * time.Millisecond, time.Second, time.Second,
+	} {
+		for range 100 {
+			// When
+			delay := policy.backoff(retry)
+
+			// Then
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling, "retry %d", retry)
+		}
+	}
+
+	assert.LessOrEqual(t, policy.backoff(100), time.Second)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		value         string
+		expectedDelay time.Duration
+		expectedOK    bool
+	}{
+		{name: "missing", value: ""},
+		{name: "seconds", value: "30", expectedDelay: 30 * time.Second, expectedOK: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "http date", value: "Mon, 01 Jan 2024 12:01:00 GMT", expectedDelay: time.Minute, expectedOK: true},
+		{name: "past http date", value: "Mon, 01 Jan 2024 11:00:00 GMT", expectedDelay: 0, expectedOK: true},
+		{name: "invalid", value: "soon"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			resp := &http.Response{Header: http.Header{}}
+			if tc.value != "" {
+				resp.Header.Set("Retry-After", tc.value)
+			}
+
+			// When
+			delay, ok := parseRetryAfter(resp, now)
+
+			// Then
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedDelay, delay)
+		})
+	}
+}
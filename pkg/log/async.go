@@ -0,0 +1,217 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// defaultAsyncBufferSize is the number of entries buffered when
+// AsyncOptions.BufferSize is not set
+const defaultAsyncBufferSize = 1024
+
+// OverflowPolicy selects what happens to entries logged while the async
+// buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes log calls wait until the background writer frees
+	// space in the buffer, so no entries are lost
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDrop discards entries while the buffer is full, so log calls
+	// never wait on a slow output. The number of dropped entries is reported
+	// on stderr.
+	OverflowDrop
+)
+
+// AsyncOptions configures asynchronous output. Entries are encoded by the
+// log call and queued in a bounded buffer, from which a background goroutine
+// writes them to the output in batches.
+//
+// Queued entries are lost if the program exits without calling Flush or
+// Close. FATAL entries flush the buffer before the program exits.
+type AsyncOptions struct {
+	// BufferSize is the number of entries that can be queued. Defaults to
+	// 1024.
+	BufferSize int
+
+	// Overflow selects whether log calls block or drop entries while the
+	// buffer is full. Defaults to OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+// SetAsync enables asynchronous output on the default logger and the loggers
+// derived from it. Passing nil flushes pending entries and restores
+// synchronous output.
+func SetAsync(opts *AsyncOptions) {
+	defaultLogger.Load().out.SetAsync(opts)
+}
+
+// Flush waits until the entries queued by the default logger have been
+// written. It returns the first write error since the previous flush.
+func Flush() error {
+	return defaultLogger.Load().flush()
+}
+
+// Close flushes the default logger and stops its background writer. Entries
+// logged afterwards are written synchronously.
+func Close() error {
+	return defaultLogger.Load().close()
+}
+
+// Flush waits until the entries queued by the logger have been written. It
+// returns the first write error since the previous flush. Flush is a no-op
+// for synchronous loggers.
+func (l *Logger) Flush() error {
+	return l.logger().flush()
+}
+
+// Close flushes the logger and stops its background writer, which is shared
+// with the loggers derived from it. Entries logged afterwards are written
+// synchronously.
+func (l *Logger) Close() error {
+	return l.logger().close()
+}
+
+// asyncWriter queues entries in a ring buffer and writes them from a
+// background goroutine
+type asyncWriter struct {
+	write    func(p []byte) (int, error)
+	overflow OverflowPolicy
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond // signaled when entries are queued or the writer is closed
+	drained  *sync.Cond // broadcast when the writer frees space or finishes a batch
+	entries  [][]byte
+	head     int
+	count    int
+	writing  bool
+	closed   bool
+	dropped  int
+	err      error
+	done     chan struct{}
+}
+
+// newAsyncWriter starts a background writer that passes batches of entries
+// to write
+func newAsyncWriter(opts AsyncOptions, write func(p []byte) (int, error)) *asyncWriter {
+	size := opts.BufferSize
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+
+	w := &asyncWriter{
+		write:    write,
+		overflow: opts.Overflow,
+		entries:  make([][]byte, size),
+		done:     make(chan struct{}),
+	}
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.drained = sync.NewCond(&w.mu)
+
+	go w.run()
+	return w
+}
+
+// enqueue queues p for writing, reporting false if the writer is closed and
+// p must be written synchronously. p is retained until it is written.
+func (w *asyncWriter) enqueue(p []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.count == len(w.entries) && !w.closed {
+		if w.overflow == OverflowDrop {
+			w.dropped++
+			return true
+		}
+		w.drained.Wait()
+	}
+
+	if w.closed {
+		return false
+	}
+
+	w.entries[(w.head+w.count)%len(w.entries)] = p
+	w.count++
+	w.notEmpty.Signal()
+	return true
+}
+
+// run writes queued entries until the writer is closed and drained
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	var batch []byte
+	for {
+		w.mu.Lock()
+		for w.count == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+
+		if w.count == 0 {
+			if w.dropped > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: %d log entries dropped, async buffer full\n", w.dropped)
+			}
+			w.mu.Unlock()
+			return
+		}
+
+		// Take every queued entry so a single write covers the batch
+		batch = batch[:0]
+		for ; w.count > 0; w.count-- {
+			batch = append(batch, w.entries[w.head]...)
+			w.entries[w.head] = nil
+			w.head = (w.head + 1) % len(w.entries)
+		}
+
+		dropped := w.dropped
+		w.dropped = 0
+		w.writing = true
+		w.drained.Broadcast()
+		w.mu.Unlock()
+
+		if dropped > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d log entries dropped, async buffer full\n", dropped)
+		}
+
+		_, err := w.write(batch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing log entry: %v\n", err)
+		}
+
+		w.mu.Lock()
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.writing = false
+		w.drained.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// Flush waits until every entry queued before the call has been written
+func (w *asyncWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.count > 0 || w.writing {
+		w.drained.Wait()
+	}
+
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// Close writes the queued entries and stops the background goroutine
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.notEmpty.Signal()
+	w.drained.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+	return w.Flush()
+}
@@ -0,0 +1,228 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks writes until its gate is opened, simulating a slow
+// output
+type gatedWriter struct {
+	gate chan struct{}
+	buf  lockedBuffer
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	return w.buf.Write(p)
+}
+
+func TestAsyncWritesAllEntriesInOrderOnFlush(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{Output: buf, Async: &AsyncOptions{BufferSize: 4}})
+	defer logger.Close()
+
+	// When
+	for i := range 50 {
+		logger.Infof("message", map[string]any{"n": i})
+	}
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	// Then
+	entries := buf.entries(t)
+	if len(entries) != 50 {
+		t.Fatalf("Got %d entries, want 50", len(entries))
+	}
+	for i, entry := range entries {
+		if n := (*entry.Fields)["n"]; n != float64(i) {
+			t.Errorf("Entry %d has n = %v, want %d", i, n, i)
+		}
+	}
+}
+
+func TestAsyncDropPolicyNeverBlocks(t *testing.T) {
+	// Given
+	out := newGatedWriter()
+	logger := New(Options{Output: out, Async: &AsyncOptions{BufferSize: 2, Overflow: OverflowDrop}})
+
+	// When - the writer is stuck, so only the buffered entries survive
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			logger.Infof("message", nil)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Log calls blocked with OverflowDrop")
+	}
+
+	close(out.gate)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// Then - at most one batch in flight plus a full buffer were kept
+	if got := len(out.buf.entries(t)); got == 0 || got > 3 {
+		t.Errorf("Got %d entries, want between 1 and 3", got)
+	}
+}
+
+func TestAsyncBlockPolicyKeepsAllEntries(t *testing.T) {
+	// Given
+	out := newGatedWriter()
+	logger := New(Options{Output: out, Async: &AsyncOptions{BufferSize: 2}})
+
+	// When
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 25 {
+				logger.Infof("message", nil)
+			}
+		}()
+	}
+
+	close(out.gate)
+	wg.Wait()
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// Then
+	if got := len(out.buf.entries(t)); got != 100 {
+		t.Errorf("Got %d entries, want 100", got)
+	}
+}
+
+func TestCloseRestoresSynchronousWrites(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{Output: buf, Async: &AsyncOptions{}})
+	logger.Infof("queued", nil)
+
+	// When
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	logger.Infof("synchronous", nil)
+
+	// Then - both entries are written without another flush
+	entries := buf.entries(t)
+	if len(entries) != 2 || entries[1].Message != "synchronous" {
+		t.Errorf("Got entries %+v, want queued then synchronous", entries)
+	}
+}
+
+func TestSetAsyncAppliesToDerivedLoggers(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &lockedBuffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	child := With(map[string]any{"component": "worker"})
+
+	// When
+	SetAsync(&AsyncOptions{})
+	defer SetAsync(nil)
+	child.Infof("child message", nil)
+	if err := Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	// Then
+	if entries := buf.entries(t); len(entries) != 1 {
+		t.Errorf("Got %d entries, want 1", len(entries))
+	}
+}
+
+// slowWriter delays every write, so queued entries are still pending when
+// the program exits unless they are flushed
+type slowWriter struct{}
+
+func (slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(50 * time.Millisecond)
+	return os.Stdout.Write(p)
+}
+
+func TestFatalFlushesAsyncBuffer(t *testing.T) {
+	if os.Getenv("LOG_TEST_FATAL") == "1" {
+		logger := New(Options{Output: slowWriter{}, Async: &AsyncOptions{}})
+		logger.Infof("before fatal", nil)
+		logger.Fatalf("fatal message", nil)
+		return
+	}
+
+	// Given
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalFlushesAsyncBuffer$")
+	cmd.Env = append(os.Environ(), "LOG_TEST_FATAL=1")
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+
+	// When
+	err := cmd.Run()
+
+	// Then
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("Process exited with %v, want exit status 1", err)
+	}
+	for _, msg := range []string{"before fatal", "fatal message"} {
+		if !strings.Contains(out.String(), msg) {
+			t.Errorf("Output is missing %q:\n%s", msg, out.String())
+		}
+	}
+}
+
+func benchmarkLogger(b *testing.B, async *AsyncOptions) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	logger := New(Options{Output: devNull, Async: async})
+	defer logger.Close()
+
+	fields := map[string]any{"method": "GET", "path": "/api/users", "status": 200}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Infof("Request processed", fields)
+		}
+	})
+	b.StopTimer()
+}
+
+func BenchmarkLoggerSync(b *testing.B) {
+	benchmarkLogger(b, nil)
+}
+
+func BenchmarkLoggerAsyncBlock(b *testing.B) {
+	benchmarkLogger(b, &AsyncOptions{})
+}
+
+func BenchmarkLoggerAsyncDrop(b *testing.B) {
+	benchmarkLogger(b, &AsyncOptions{Overflow: OverflowDrop})
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth bounds the number of frames recorded in a stack trace
+const maxStackDepth = 64
+
+// packageDir is the directory of this package's source files, used to skip
+// logging frames when looking for the caller
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// SetCaller enables or disables recording the caller's file:line as the
+// "caller" field of entries written by the default logger and the loggers
+// derived from it
+func SetCaller(enabled bool) {
+	defaultLogger.Load().caller.Store(enabled)
+}
+
+// SetStackTrace enables or disables recording a stack trace as the
+// "stacktrace" field of ERROR, PANIC and FATAL entries written by the default logger
+// and the loggers derived from it
+func SetStackTrace(enabled bool) {
+	defaultLogger.Load().stacktrace.Store(enabled)
+}
+
+// captureFields returns the caller and stack trace fields enabled for an
+// entry at the given level, or nil if none are enabled
+func (l *jsonLogger) captureFields(level logLevel) map[string]any {
+	withCaller := l.caller.Load()
+	withStack := l.stacktrace.Load() && (level == errorlevel || level == paniclevel || level == fatallevel)
+	if !withCaller && !withStack {
+		return nil
+	}
+
+	caller, stack := captureStack(withStack)
+	if caller == "" {
+		return nil
+	}
+
+	fields := make(map[string]any, 2)
+	if withCaller {
+		fields["caller"] = caller
+	}
+	if withStack {
+		fields["stacktrace"] = stack
+	}
+	return fields
+}
+
+// captureStack returns the first frame outside of the logging packages as
+// "dir/file.go:line" and, if requested, the stack trace starting at that frame
+func captureStack(withStack bool) (string, string) {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var caller string
+	var stack strings.Builder
+	for {
+		frame, more := frames.Next()
+		if caller == "" && isLoggingFrame(frame) {
+			if !more {
+				break
+			}
+			continue
+		}
+
+		if caller == "" {
+			caller = shortCaller(frame)
+			if !withStack {
+				break
+			}
+		}
+
+		stack.WriteString(frame.Function)
+		stack.WriteString("\n\t")
+		stack.WriteString(frame.File)
+		stack.WriteByte(':')
+		stack.WriteString(strconv.Itoa(frame.Line))
+		stack.WriteByte('\n')
+
+		if !more {
+			break
+		}
+	}
+
+	return caller, strings.TrimSuffix(stack.String(), "\n")
+}
+
+// isLoggingFrame reports whether the frame belongs to pkg/log or log/slog,
+// which sit between the caller and the logger
+func isLoggingFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "log/slog.") {
+		return true
+	}
+
+	return filepath.Dir(frame.File) == packageDir && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// shortCaller formats a frame as its last directory, file name and line
+func shortCaller(frame runtime.Frame) string {
+	dir, file := filepath.Split(frame.File)
+	return filepath.Join(filepath.Base(dir), file) + ":" + strconv.Itoa(frame.Line)
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCallerPointsToLoggingCallSite(t *testing.T) {
+	testCases := []struct {
+		name string
+		log  func(logger *Logger)
+	}{
+		{
+			name: "logger method",
+			log:  func(logger *Logger) { logger.Infof("message", nil) },
+		},
+		{
+			name: "derived logger",
+			log:  func(logger *Logger) { logger.With(map[string]any{"k": "v"}).Warnf("message", nil) },
+		},
+		{
+			name: "context method",
+			log:  func(logger *Logger) { logger.InfoCtx(context.Background(), "message", nil) },
+		},
+		{
+			name: "slog handler",
+			log:  func(logger *Logger) { slog.New(logger.Handler()).Info("message") },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			buf := &bytes.Buffer{}
+			logger := New(Options{Output: buf, AddCaller: true})
+
+			// When
+			tc.log(logger)
+
+			// Then
+			var entry testLogEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+			}
+
+			caller, _ := (*entry.Fields)["caller"].(string)
+			if !strings.HasPrefix(caller, "log/caller_test.go:") {
+				t.Errorf("Field caller = %q, want log/caller_test.go:<line>", caller)
+			}
+		})
+	}
+}
+
+func TestPackageFunctionsRecordCaller(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+	SetCaller(true)
+
+	// When
+	Infof("message", nil)
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+
+	caller, _ := (*entry.Fields)["caller"].(string)
+	if !strings.HasPrefix(caller, "log/caller_test.go:") {
+		t.Errorf("Field caller = %q, want log/caller_test.go:<line>", caller)
+	}
+}
+
+func TestStackTraceOnlyForErrorLevels(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{Output: buf, StackTrace: true})
+
+	// When
+	logger.Infof("info message", nil)
+	logger.Errorf("error message", nil)
+
+	// Then
+	entries := buf.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Got %d entries, want 2", len(entries))
+	}
+
+	if entries[0].Fields != nil {
+		if _, ok := (*entries[0].Fields)["stacktrace"]; ok {
+			t.Errorf("INFO entry has a stacktrace field")
+		}
+	}
+
+	stack, _ := (*entries[1].Fields)["stacktrace"].(string)
+	if !strings.HasPrefix(stack, "github.com/CloudLearnersOrg/golib/pkg/log.TestStackTraceOnlyForErrorLevels") {
+		t.Errorf("Field stacktrace should start at the test function, got:\n%s", stack)
+	}
+	if _, ok := (*entries[1].Fields)["caller"]; ok {
+		t.Errorf("Field caller should only be recorded with AddCaller")
+	}
+}
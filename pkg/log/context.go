@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+)
+
+// contextKey is used to store request identifiers in a context.Context
+type contextKey string
+
+const (
+	traceIDContextKey contextKey = "trace_id"
+	spanIDContextKey  contextKey = "span_id"
+	userIDContextKey  contextKey = "user_id"
+)
+
+// Keys under which the logger middleware and the session middleware store
+// request identifiers in a *gin.Context
+const (
+	ginTraceIDKey = "X-Trace-ID"
+	ginSpanIDKey  = "X-Span-ID"
+	ginUserIDKey  = "user_id" // session.UserKey
+)
+
+// ginKeyGetter matches *gin.Context, which stores request-scoped values in
+// its Keys map rather than in the context chain
+type ginKeyGetter interface {
+	Get(key string) (any, bool)
+}
+
+// ContextWithTraceID returns a copy of ctx carrying the trace ID, which is
+// added as "trace_id" to entries logged with the *Ctx functions
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// ContextWithSpanID returns a copy of ctx carrying the span ID, which is
+// added as "span_id" to entries logged with the *Ctx functions
+func ContextWithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDContextKey, spanID)
+}
+
+// ContextWithUserID returns a copy of ctx carrying the user ID, which is
+// added as "user_id" to entries logged with the *Ctx functions
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// TraceIDFromContext returns the trace ID logged for entries logged with ctx,
+// or an empty string
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := contextFields(ctx)["trace_id"].(string)
+	return traceID
+}
+
+// SpanIDFromContext returns the span ID logged for entries logged with ctx, or
+// an empty string
+func SpanIDFromContext(ctx context.Context) string {
+	spanID, _ := contextFields(ctx)["span_id"].(string)
+	return spanID
+}
+
+// contextFields extracts the trace ID, span ID and user ID from ctx. Values
+// set with the ContextWith* functions take precedence over the span context
+// of pkg/tracectx, which takes precedence over values stored in a
+// *gin.Context.
+func contextFields(ctx context.Context) map[string]any {
+	if ctx == nil {
+		return nil
+	}
+
+	span, _ := tracectx.FromContext(ctx)
+
+	var fields map[string]any
+	add := func(field string, key contextKey, spanValue string, ginKey string) {
+		value := ctx.Value(key)
+		if value == nil && spanValue != "" {
+			value = spanValue
+		}
+		if value == nil {
+			if gctx, ok := ctx.(ginKeyGetter); ok {
+				value, _ = gctx.Get(ginKey)
+			}
+		}
+
+		if value == nil || value == "" {
+			return
+		}
+
+		if fields == nil {
+			fields = make(map[string]any, 3)
+		}
+		fields[field] = value
+	}
+
+	add("trace_id", traceIDContextKey, span.TraceID, ginTraceIDKey)
+	add("span_id", spanIDContextKey, span.SpanID, ginSpanIDKey)
+	add("user_id", userIDContextKey, "", ginUserIDKey)
+	return fields
+}
+
+// logCtx logs the entry with the identifiers found in ctx added to fields.
+// Fields passed by the caller override identifiers from the context.
+func (l *jsonLogger) logCtx(ctx context.Context, level logLevel, msg string, fields map[string]any) {
+	// Skip the context lookup for entries that will not be logged
+	if !l.shouldLog(level) {
+		return
+	}
+
+	ctxFields := contextFields(ctx)
+	if len(ctxFields) == 0 {
+		l.log(level, msg, fields)
+		return
+	}
+
+	l.log(level, msg, *mergeFields(ctxFields, fields))
+}
@@ -0,0 +1,187 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCtxFunctionsAddIdentifiersFromContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("X-Trace-ID", "gin-trace")
+	ginCtx.Set("X-Span-ID", "gin-span")
+	ginCtx.Set("user_id", "gin-user")
+
+	plainCtx := ContextWithTraceID(context.Background(), "plain-trace")
+	plainCtx = ContextWithSpanID(plainCtx, "plain-span")
+	plainCtx = ContextWithUserID(plainCtx, "plain-user")
+
+	spanCtx := tracectx.ContextWithSpan(context.Background(), tracectx.SpanContext{TraceID: "span-trace", SpanID: "span-span"})
+	spanCtx = ContextWithSpanID(spanCtx, "explicit-span")
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		expected map[string]any
+	}{
+		{
+			name: "gin context",
+			ctx:  ginCtx,
+			expected: map[string]any{
+				"trace_id": "gin-trace",
+				"span_id":  "gin-span",
+				"user_id":  "gin-user",
+			},
+		},
+		{
+			name: "plain context",
+			ctx:  plainCtx,
+			expected: map[string]any{
+				"trace_id": "plain-trace",
+				"span_id":  "plain-span",
+				"user_id":  "plain-user",
+			},
+		},
+		{
+			name: "tracectx span context",
+			ctx:  spanCtx,
+			expected: map[string]any{
+				"trace_id": "span-trace",
+				"span_id":  "explicit-span",
+			},
+		},
+		{
+			name:     "context without identifiers",
+			ctx:      context.Background(),
+			expected: map[string]any{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			buf := &bytes.Buffer{}
+			logger := New(Options{Output: buf})
+
+			// When
+			logger.InfoCtx(tc.ctx, "context message", map[string]any{"local": "value"})
+
+			// Then
+			var entry testLogEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+			}
+
+			fields := *entry.Fields
+			if fields["local"] != "value" {
+				t.Errorf("Field local = %v, want %v", fields["local"], "value")
+			}
+
+			for _, key := range []string{"trace_id", "span_id", "user_id"} {
+				if fields[key] != tc.expected[key] {
+					t.Errorf("Field %s = %v, want %v", key, fields[key], tc.expected[key])
+				}
+			}
+		})
+	}
+}
+
+func TestCtxFieldsAreOverriddenByCallerFields(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+	ctx := ContextWithTraceID(context.Background(), "context-trace")
+
+	// When
+	logger.WarnCtx(ctx, "override message", map[string]any{"trace_id": "explicit-trace"})
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+
+	if (*entry.Fields)["trace_id"] != "explicit-trace" {
+		t.Errorf("Field trace_id = %v, want %v", (*entry.Fields)["trace_id"], "explicit-trace")
+	}
+}
+
+func TestPackageCtxFunctionsUseDefaultLogger(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	testLogger := newJSONLogger(tracelevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	ctx := ContextWithTraceID(context.Background(), "trace-123")
+	logFuncs := []func(ctx context.Context, msg string, fields map[string]any){
+		TraceCtx, DebugCtx, InfoCtx, WarnCtx, ErrorCtx,
+	}
+
+	for _, logFunc := range logFuncs {
+		buf.Reset()
+
+		// When
+		logFunc(ctx, "package message", nil)
+
+		// Then
+		var entry testLogEntry
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+		}
+
+		if entry.Fields == nil || (*entry.Fields)["trace_id"] != "trace-123" {
+			t.Errorf("Expected trace_id in %s entry, got %+v", entry.Level, entry.Fields)
+		}
+	}
+}
+
+func TestHandlerAddsIdentifiersFromContext(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	slogger := slog.New(New(Options{Output: buf}).Handler())
+	ctx := ContextWithTraceID(context.Background(), "slog-trace")
+
+	// When
+	slogger.InfoContext(ctx, "slog message")
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+
+	if entry.Fields == nil || (*entry.Fields)["trace_id"] != "slog-trace" {
+		t.Errorf("Expected trace_id from context, got %+v", entry.Fields)
+	}
+}
+
+func TestIDsFromContext(t *testing.T) {
+	// Given
+	ctx := ContextWithSpanID(ContextWithTraceID(context.Background(), "trace-123"), "span-456")
+
+	// When
+	traceID, spanID := TraceIDFromContext(ctx), SpanIDFromContext(ctx)
+
+	// Then
+	if traceID != "trace-123" {
+		t.Errorf("TraceIDFromContext = %q, want %q", traceID, "trace-123")
+	}
+	if spanID != "span-456" {
+		t.Errorf("SpanIDFromContext = %q, want %q", spanID, "span-456")
+	}
+	if got := TraceIDFromContext(context.Background()); got != "" {
+		t.Errorf("TraceIDFromContext of an empty context = %q, want none", got)
+	}
+}
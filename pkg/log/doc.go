@@ -3,12 +3,13 @@
 // thread-safe operations.
 //
 // Log Levels:
-// The package supports six logging levels in order of increasing severity:
+// The package supports seven logging levels in order of increasing severity:
 //   - TRACE: Verbose debugging information
 //   - DEBUG: Debugging information
 //   - INFO: General operational information (default)
 //   - WARN: Warning messages for potentially harmful situations
 //   - ERROR: Error messages for serious problems
+//   - PANIC: Errors that abort the current goroutine with a panic
 //   - FATAL: Critical errors that result in program termination
 //
 // Basic Usage:
@@ -43,8 +44,195 @@
 //	file, _ := os.OpenFile("app.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 //	log.SetOutput(file)
 //
+// Output Formats:
+// Entries are written as JSON by default. The logfmt and console formats can
+// be selected with SetFormat, Options.Format or the LOG_FORMAT environment
+// variable; the console format aligns and colorizes levels for local
+// development and honors NO_COLOR:
+//
+//	log.SetFormat(log.FormatConsole)
+//	// 15:04:05.000 INFO  Request processed  method=GET path=/api/users
+//
+//	log.SetFormat(log.FormatLogfmt)
+//	// time=2024-04-22T15:04:05Z level=INFO msg="Request processed" method=GET path=/api/users
+//
+// Custom formats implement the Encoder interface and are installed with
+// SetEncoder or Options.Encoder.
+//
+// Sinks:
+// Entries can be written to several destinations, each with its own minimum
+// level, format and filter. RotatingFile rotates a file by size or age and
+// removes old files:
+//
+//	errorFile, err := log.NewRotatingFile(log.RotatingFileOptions{
+//	    Filename:   "/var/log/app/error.log",
+//	    MaxSize:    100 << 20,
+//	    MaxBackups: 7,
+//	    MaxAge:     30 * 24 * time.Hour,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//
+//	log.SetSinks(
+//	    log.Sink{Output: os.Stdout, Level: "info"},
+//	    log.Sink{Output: os.Stderr, Level: "error", Format: log.FormatConsole},
+//	    log.Sink{Output: errorFile, Level: "error"},
+//	)
+//
+// Asynchronous Output:
+// With async output enabled, entries are queued in a bounded buffer and
+// written in batches by a background goroutine. When the buffer is full, log
+// calls either wait (OverflowBlock, the default) or drop the entry
+// (OverflowDrop). Queued entries must be flushed before the program exits;
+// FATAL entries flush automatically:
+//
+//	log.SetAsync(&log.AsyncOptions{BufferSize: 4096, Overflow: log.OverflowDrop})
+//	defer log.Close()
+//
+// Redaction:
+// Values of fields whose keys end with a sensitive word ("password",
+// "secret", "token", "authorization", ...) are replaced with "[REDACTED]"
+// before entries are marshaled, including inside nested maps and structs.
+// Keys are split into words at "-", "_", "." and camelCase boundaries, so
+// "access_token" and "accessToken" are redacted while "token_count" is not.
+// Struct fields can also be marked explicitly:
+//
+//	type Credentials struct {
+//	    Username string `json:"username"`
+//	    PIN      string `json:"pin" log:"redact"`
+//	}
+//
+// The patterns can be replaced, and the redactor is reused by the logger
+// middleware and the ginhttp client to mask headers and query strings:
+//
+//	log.SetRedactor(log.NewRedactor("password", "ssn", "card_number"))
+//	masked := log.Default().Redactor().Query(req.URL.RawQuery)
+//
+// Callers, Stack Traces and Errors:
+// The logger can record where each entry was logged from as a "caller"
+// field, and a stack trace as a "stacktrace" field of ERROR, PANIC and FATAL
+// entries. Both are disabled by default:
+//
+//	log.SetCaller(true)
+//	log.SetStackTrace(true)
+//	logger := log.New(log.Options{AddCaller: true, StackTrace: true})
+//
+// Error values in fields are written with their message, type and wrapped
+// errors, following errors.Unwrap ("cause") and errors.Join ("errors"):
+//
+//	log.Errorf("Query failed", map[string]any{"error": err})
+//	// "error":{"message":"query users: connection refused","type":"*fmt.wrapError","cause":{...}}
+//
+// Fatal Errors and Panics:
+// FATAL entries exit the program with status 1. os.Exit skips deferred
+// calls, so cleanup is registered as hooks that run before exiting, and the
+// exit itself can be replaced in tests:
+//
+//	log.RegisterFatalHook(db.Close)
+//	log.SetExitFunc(func(code int) { exitCode = code })
+//
+// Panicf logs at PANIC level and panics with a *PanicError carrying the
+// message and fields, so the failure can be recovered instead of exiting.
+//
+// Sampling:
+// Repeated entries can be sampled so noisy code paths, such as failing
+// database validation queries, cannot saturate log shipping. Entries are
+// grouped by level and message; after the first Initial entries in an
+// interval only every Thereafter-th entry is written, and the number of
+// dropped entries is written periodically as a summary entry:
+//
+//	log.SetSampling(&log.SamplingOptions{
+//	    Interval:        time.Second,
+//	    Initial:         10,
+//	    Thereafter:      100,
+//	    SummaryInterval: 30 * time.Second,
+//	})
+//
+// Logger Instances:
+// Independent loggers can be created with New, and child loggers with
+// request-scoped fields can be derived with With. Children never modify
+// their parent, so fields attached in one handler do not leak into others:
+//
+//	logger := log.New(log.Options{
+//	    Level:  "DEBUG",
+//	    Output: os.Stderr,
+//	    Fields: map[string]any{"service": "api"},
+//	})
+//
+//	reqLogger := logger.With(map[string]any{"request_id": id})
+//	reqLogger.Infof("Processing request", nil)
+//
+// The package-level functions log through a default logger, which can be
+// replaced with SetDefault and retrieved with Default:
+//
+//	log.SetDefault(logger)
+//	log.With(map[string]any{"user_id": userID}).Warnf("Quota exceeded", nil)
+//
+// A nil or zero Logger logs through the default logger, and SetDefault(nil)
+// leaves the default logger unchanged.
+//
+// Runtime Level Control:
+// Levels can be raised temporarily, reverting automatically, and overridden
+// for named loggers. An override for "db" also applies to "db.pool":
+//
+//	log.SetLevelFor("debug", 10*time.Minute)
+//
+//	dbLogger := log.Default().Named("db")
+//	log.SetLevelOverride("db", "trace")
+//
+// LevelHandler and GinLevelHandler expose the same controls over HTTP, and
+// must only be mounted on internal or authenticated routes:
+//
+//	admin.GET("/log/level", log.GinLevelHandler())
+//	admin.PUT("/log/level", log.GinLevelHandler())
+//
+//	// curl -X PUT -d '{"level":"debug","logger":"db","ttl":"5m"}' .../log/level
+//
+// Context-Aware Logging:
+// The *Ctx variants of the logging functions add request identifiers found in
+// a context.Context as "trace_id", "span_id" and "user_id" fields. Trace and
+// span IDs are read from the span context of pkg/tracectx, which the logger
+// middleware stores in the request context. A *gin.Context is searched for the
+// values stored by the logger middleware ("X-Trace-ID", "X-Span-ID") and the
+// session middleware (session.UserKey); plain contexts carry them with
+// ContextWithTraceID, ContextWithSpanID and ContextWithUserID:
+//
+//	func handler(c *gin.Context) {
+//	    log.InfoCtx(c, "Fetching user", map[string]any{"id": c.Param("id")})
+//	}
+//
+//	ctx = log.ContextWithTraceID(ctx, traceID)
+//	logger.ErrorCtx(ctx, "Job failed", nil)
+//
+// TraceIDFromContext and SpanIDFromContext return the IDs that would be
+// logged, so that the ginhttp client continues the same trace.
+//
+// slog Integration:
+// The logger middleware and the ginhttp client log through log/slog. A single
+// call makes slog's default logger write through pkg/log, so every golib
+// package emits the same entry format:
+//
+//	log.SetSlogDefault()
+//
+// Conversely, pkg/log entries can be routed into any slog.Handler, with fields
+// converted to attributes and levels mapped to LevelTrace, slog.LevelDebug,
+// slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelPanic and LevelFatal:
+//
+//	log.SetHandler(slog.NewTextHandler(os.Stderr, nil))
+//
+// Any Logger can also be used directly as a slog.Handler:
+//
+//	slogger := slog.New(logger.Handler())
+//
+// Testing:
+// The logtest subpackage captures entries in memory for assertions:
+//
+//	rec := logtest.New(t)
+//	rec.AssertContains("ERROR", "Query failed", map[string]any{"table": "users"})
+//
 // JSON Output Format:
-// All log entries are formatted as JSON with the following structure:
+// With the default JSON format, log entries have the following structure:
 //
 //	{
 //	    "timestamp": "2024-04-22T15:04:05Z07:00",
@@ -59,11 +247,16 @@
 //
 // Thread Safety:
 // The logger is safe for concurrent use by multiple goroutines. All logging
-// operations are atomic and will not produce interleaved output.
+// operations are atomic and will not produce interleaved output. The level is
+// stored atomically, fields set with SetFields are replaced copy-on-write so
+// in-flight log calls are never affected, and writes to the output are
+// serialized. Child loggers created with With share their parent's level and
+// output.
 //
 // Performance:
 // The logger implements several optimizations:
 //   - JSON marshaling only occurs if the message will be logged
 //   - Fields are allocated only when needed
 //   - Log level checks are performed early to avoid unnecessary work
+//   - Optional asynchronous output moves writes off the calling goroutine
 package log
@@ -0,0 +1,253 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats selectable with SetFormat, Options.Format or the LOG_FORMAT
+// environment variable
+const (
+	FormatJSON    = "json"
+	FormatLogfmt  = "logfmt"
+	FormatConsole = "console"
+)
+
+// formatEnvVar names the environment variable that selects the default format
+const formatEnvVar = "LOG_FORMAT"
+
+// Entry is a single log entry as passed to an Encoder
+type Entry struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]any
+}
+
+// Encoder formats entries for output. Encode returns a single line without
+// the trailing newline, which is added by the logger.
+type Encoder interface {
+	Encode(entry Entry) ([]byte, error)
+}
+
+// SetFormat selects the output format of the default logger and the loggers
+// derived from it. Unknown formats leave the current format unchanged.
+func SetFormat(format string) {
+	if enc, ok := parseFormat(format); ok {
+		SetEncoder(enc)
+	}
+}
+
+// SetEncoder replaces the encoder of the default logger and the loggers
+// derived from it
+func SetEncoder(enc Encoder) {
+	defaultLogger.Load().encoder.Store(enc)
+}
+
+// parseFormat returns the encoder for a format name, warning about unknown
+// formats
+func parseFormat(format string) (Encoder, bool) {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return JSONEncoder{}, true
+	case FormatLogfmt:
+		return LogfmtEncoder{}, true
+	case FormatConsole:
+		_, noColor := os.LookupEnv("NO_COLOR")
+		return ConsoleEncoder{NoColor: noColor}, true
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: Unknown log format '%s', keeping current format\n", format)
+		return nil, false
+	}
+}
+
+// defaultEncoder returns the encoder selected by LOG_FORMAT, or JSON
+func defaultEncoder() Encoder {
+	if format := os.Getenv(formatEnvVar); format != "" {
+		if enc, ok := parseFormat(format); ok {
+			return enc
+		}
+	}
+	return JSONEncoder{}
+}
+
+// JSONEncoder writes entries as JSON objects:
+//
+//	{"timestamp":"2024-04-22T15:04:05Z","level":"INFO","message":"Request processed","fields":{"method":"GET"}}
+//
+// Error values, which encoding/json would write as {}, are written with their
+// message, type and wrapped errors.
+type JSONEncoder struct{}
+
+// Encode implements Encoder
+func (JSONEncoder) Encode(entry Entry) ([]byte, error) {
+	e := logEntry{
+		Timestamp: entry.Time.Format(time.RFC3339),
+		Level:     entry.Level,
+		Message:   entry.Message,
+	}
+
+	if len(entry.Fields) > 0 {
+		fields := serializeErrors(entry.Fields)
+		e.Fields = &fields
+	}
+
+	return json.Marshal(e)
+}
+
+// LogfmtEncoder writes entries as logfmt key=value pairs with fields sorted
+// by key:
+//
+//	time=2024-04-22T15:04:05Z level=INFO msg="Request processed" method=GET
+type LogfmtEncoder struct{}
+
+// Encode implements Encoder
+func (LogfmtEncoder) Encode(entry Entry) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("time=")
+	buf.WriteString(entry.Time.Format(time.RFC3339))
+	buf.WriteString(" level=")
+	buf.WriteString(entry.Level)
+	buf.WriteString(" msg=")
+	writeLogfmtValue(buf, entry.Message)
+
+	for _, k := range sortedKeys(entry.Fields) {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(k))
+		buf.WriteByte('=')
+		writeLogfmtValue(buf, formatValue(entry.Fields[k]))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ConsoleEncoder writes human-readable entries for local development, with
+// levels aligned and colorized:
+//
+//	15:04:05.000 INFO  Request processed  method=GET path=/api/users
+type ConsoleEncoder struct {
+	// NoColor disables ANSI colors, as when the NO_COLOR environment
+	// variable is set
+	NoColor bool
+}
+
+// levelColors are the ANSI color codes used by ConsoleEncoder
+var levelColors = map[string]string{
+	string(tracelevel): "90",
+	string(debuglevel): "36",
+	string(infolevel):  "32",
+	string(warnlevel):  "33",
+	string(errorlevel): "31",
+	string(paniclevel): "91",
+	string(fatallevel): "35",
+}
+
+// Encode implements Encoder
+func (e ConsoleEncoder) Encode(entry Entry) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(entry.Time.Format("15:04:05.000"))
+	buf.WriteByte(' ')
+
+	level := fmt.Sprintf("%-5s", entry.Level)
+	if color, ok := levelColors[entry.Level]; ok && !e.NoColor {
+		level = "\x1b[" + color + "m" + level + "\x1b[0m"
+	}
+	buf.WriteString(level)
+	buf.WriteByte(' ')
+	buf.WriteString(entry.Message)
+
+	keys := sortedKeys(entry.Fields)
+	if len(keys) > 0 {
+		buf.WriteString("  ")
+	}
+
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+
+		key := k + "="
+		if !e.NoColor {
+			key = "\x1b[2m" + key + "\x1b[0m"
+		}
+		buf.WriteString(key)
+		writeLogfmtValue(buf, formatValue(entry.Fields[k]))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatValue converts a field value to its text form. Strings are kept,
+// scalars use their natural representation, and composite values are
+// encoded as JSON.
+func formatValue(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case time.Duration:
+		return value.String()
+	case time.Time:
+		return value.Format(time.RFC3339)
+	case error:
+		if isNilError(value) {
+			return "null"
+		}
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// writeLogfmtValue writes s, quoting it when it is empty or contains
+// spaces, quotes, '=' or control characters
+func writeLogfmtValue(buf *bytes.Buffer, s string) {
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f
+	}) {
+		buf.WriteString(strconv.Quote(s))
+		return
+	}
+	buf.WriteString(s)
+}
+
+// logfmtKey replaces characters that are not allowed in logfmt keys
+func logfmtKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, k)
+}
+
+// sortedKeys returns the keys of fields in ascending order
+func sortedKeys(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenEntries covers messages with and without fields, every level and
+// values that need quoting or JSON encoding
+func goldenEntries() []Entry {
+	ts := time.Date(2024, 4, 22, 15, 4, 5, 123000000, time.UTC)
+	return []Entry{
+		{Time: ts, Level: "INFO", Message: "Application starting"},
+		{Time: ts, Level: "TRACE", Message: "entering handler", Fields: map[string]any{"depth": 3}},
+		{Time: ts, Level: "DEBUG", Message: "cache lookup", Fields: map[string]any{"hit": false, "key": "user:42"}},
+		{Time: ts, Level: "INFO", Message: "Request processed", Fields: map[string]any{
+			"method":   "GET",
+			"path":     "/api/users",
+			"duration": 125 * time.Millisecond,
+			"status":   200,
+		}},
+		{Time: ts, Level: "WARN", Message: "Host validation failed", Fields: map[string]any{
+			"requestHost":   "evil.com",
+			"expectedHosts": []string{"api.example.com", "localhost:3000"},
+		}},
+		{Time: ts, Level: "ERROR", Message: "validation query failed", Fields: map[string]any{
+			"error": errors.New(`connection refused: "db:5432"`),
+			"query": "SELECT 1",
+			"empty": "",
+		}},
+		{Time: ts, Level: "FATAL", Message: "cannot start", Fields: map[string]any{"ratio": 0.25, "missing": nil}},
+	}
+}
+
+func TestEncodersMatchGoldenFiles(t *testing.T) {
+	testCases := []struct {
+		name    string
+		encoder Encoder
+	}{
+		{FormatJSON, JSONEncoder{}},
+		{FormatLogfmt, LogfmtEncoder{}},
+		{FormatConsole, ConsoleEncoder{}},
+		{FormatConsole + "_nocolor", ConsoleEncoder{NoColor: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			buf := &bytes.Buffer{}
+
+			// When
+			for _, entry := range goldenEntries() {
+				data, err := tc.encoder.Encode(entry)
+				if err != nil {
+					t.Fatalf("Encode failed: %v", err)
+				}
+				buf.Write(data)
+				buf.WriteByte('\n')
+			}
+
+			// Then
+			golden := filepath.Join("testdata", tc.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o600); err != nil {
+					t.Fatalf("Failed to update golden file: %v", err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read golden file: %v", err)
+			}
+
+			if buf.String() != string(expected) {
+				t.Errorf("Output does not match %s\ngot:\n%s\nwant:\n%s", golden, buf.String(), expected)
+			}
+		})
+	}
+}
+
+func TestSetFormatChangesEncoder(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	child := With(map[string]any{"component": "api"})
+
+	// When
+	SetFormat("logfmt")
+	child.Infof("logfmt message", nil)
+	SetFormat("unknown")
+	child.Infof("still logfmt", nil)
+
+	// Then
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", len(lines), buf.String())
+	}
+
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "time=") || !strings.HasSuffix(line, "component=api") {
+			t.Errorf("Expected logfmt output, got %q", line)
+		}
+	}
+}
+
+func TestFormatOptionAndEnvironment(t *testing.T) {
+	// Given
+	t.Setenv("LOG_FORMAT", "console")
+	envBuf := &bytes.Buffer{}
+	optionBuf := &bytes.Buffer{}
+
+	// When
+	New(Options{Output: envBuf}).Infof("from env", nil)
+	New(Options{Output: optionBuf, Format: "logfmt"}).Infof("from option", nil)
+
+	// Then
+	if !strings.Contains(envBuf.String(), "INFO") || strings.HasPrefix(envBuf.String(), "{") || strings.HasPrefix(envBuf.String(), "time=") {
+		t.Errorf("Expected console output from LOG_FORMAT, got %q", envBuf.String())
+	}
+
+	if !strings.HasPrefix(optionBuf.String(), "time=") {
+		t.Errorf("Expected Format option to override LOG_FORMAT, got %q", optionBuf.String())
+	}
+}
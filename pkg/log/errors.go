@@ -0,0 +1,146 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// maxErrorDepth bounds how deep wrapped error chains are serialized
+const maxErrorDepth = 16
+
+// errorValue converts an error into a structured value with its message, its
+// type and the errors it wraps. A single wrapped error (errors.Unwrap) is
+// recorded as "cause" and errors combined with errors.Join as "errors":
+//
+//	{
+//	    "message": "connect to database: dial tcp: connection refused",
+//	    "type": "*fmt.wrapError",
+//	    "cause": {"message": "dial tcp: connection refused", "type": "*net.OpError"}
+//	}
+func errorValue(err error) any {
+	return errorValueDepth(err, 0)
+}
+
+// errorValueDepth converts err, stopping once maxErrorDepth is reached. A
+// nil pointer stored in an error is converted to nil, which marshals as null,
+// without calling its Error method.
+func errorValueDepth(err error, depth int) any {
+	if isNilError(err) {
+		return nil
+	}
+
+	value := map[string]any{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+
+	if depth >= maxErrorDepth {
+		return value
+	}
+
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := wrapped.Unwrap(); cause != nil {
+			value["cause"] = errorValueDepth(cause, depth+1)
+		}
+	case interface{ Unwrap() []error }:
+		var errs []any
+		for _, e := range wrapped.Unwrap() {
+			if e != nil {
+				errs = append(errs, errorValueDepth(e, depth+1))
+			}
+		}
+		if len(errs) > 0 {
+			value["errors"] = errs
+		}
+	}
+
+	return value
+}
+
+// isNilError reports whether err is nil or holds a nil pointer, map, slice
+// or func, whose Error method may dereference it
+func isNilError(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(err); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// serializeErrors returns fields with error values, including errors nested
+// in maps and slices, replaced by their structured form. fields is returned
+// unchanged, and not copied, if it contains no errors.
+func serializeErrors(fields map[string]any) map[string]any {
+	serialized, _ := serializeErrorFields(fields)
+	return serialized
+}
+
+// serializeErrorFields converts the errors in fields, reporting whether any
+// were found
+func serializeErrorFields(fields map[string]any) (map[string]any, bool) {
+	var serialized map[string]any
+	for k, v := range fields {
+		converted, changed := serializeErrorValue(v)
+		if !changed {
+			continue
+		}
+
+		if serialized == nil {
+			serialized = make(map[string]any, len(fields))
+			for key, value := range fields {
+				serialized[key] = value
+			}
+		}
+		serialized[k] = converted
+	}
+
+	if serialized == nil {
+		return fields, false
+	}
+	return serialized, true
+}
+
+// serializeErrorValue converts v if it is or contains an error, reporting
+// whether it was converted
+func serializeErrorValue(v any) (any, bool) {
+	switch value := v.(type) {
+	case error:
+		return errorValue(value), true
+	case map[string]any:
+		return serializeErrorFields(value)
+	case []error:
+		errs := make([]any, 0, len(value))
+		for _, err := range value {
+			if err != nil {
+				errs = append(errs, errorValue(err))
+			}
+		}
+		return errs, true
+	case []any:
+		var items []any
+		for i, item := range value {
+			converted, changed := serializeErrorValue(item)
+			if !changed {
+				continue
+			}
+
+			if items == nil {
+				items = append([]any(nil), value...)
+			}
+			items[i] = converted
+		}
+
+		if items == nil {
+			return v, false
+		}
+		return items, true
+	default:
+		return v, false
+	}
+}
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorFieldsAreSerializedWithChain(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+
+	base := errors.New("connection refused")
+	wrapped := fmt.Errorf("connect to database: %w", base)
+	joined := errors.Join(errors.New("first"), errors.New("second"))
+
+	// When
+	logger.Errorf("query failed", map[string]any{
+		"error":  wrapped,
+		"joined": joined,
+		"nested": map[string]any{"err": base},
+	})
+
+	// Then
+	var entry struct {
+		Fields map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	errField, _ := entry.Fields["error"].(map[string]any)
+	if errField["message"] != "connect to database: connection refused" {
+		t.Errorf("error.message = %v, want %q", errField["message"], "connect to database: connection refused")
+	}
+	if errField["type"] != "*fmt.wrapError" {
+		t.Errorf("error.type = %v, want %q", errField["type"], "*fmt.wrapError")
+	}
+
+	cause, _ := errField["cause"].(map[string]any)
+	if cause["message"] != "connection refused" || cause["type"] != "*errors.errorString" {
+		t.Errorf("error.cause = %v, want the wrapped error", cause)
+	}
+
+	joinedField, _ := entry.Fields["joined"].(map[string]any)
+	errs, _ := joinedField["errors"].([]any)
+	if len(errs) != 2 {
+		t.Fatalf("joined.errors has %d entries, want 2", len(errs))
+	}
+	if first, _ := errs[0].(map[string]any); first["message"] != "first" {
+		t.Errorf("joined.errors[0].message = %v, want %q", first["message"], "first")
+	}
+
+	nested, _ := entry.Fields["nested"].(map[string]any)
+	if nestedErr, _ := nested["err"].(map[string]any); nestedErr["message"] != "connection refused" {
+		t.Errorf("nested.err = %v, want a serialized error", nested["err"])
+	}
+}
+
+type testQueryError struct {
+	query string
+}
+
+func (e *testQueryError) Error() string {
+	return "query failed: " + e.query
+}
+
+func TestTypedNilErrorsAreSerializedAsNull(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+
+	var queryErr *testQueryError
+	var err error = queryErr
+
+	// When
+	logger.Errorf("query finished", map[string]any{
+		"error":  err,
+		"errors": []error{err},
+	})
+
+	// Then
+	var entry struct {
+		Fields map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	if value, ok := entry.Fields["error"]; !ok || value != nil {
+		t.Errorf("error = %v, want null", value)
+	}
+	if errs, _ := entry.Fields["errors"].([]any); len(errs) != 1 || errs[0] != nil {
+		t.Errorf("errors = %v, want [null]", entry.Fields["errors"])
+	}
+}
+
+func TestSerializeErrorsDoesNotCopyWithoutErrors(t *testing.T) {
+	// Given
+	fields := map[string]any{"key": "value", "list": []any{1, "two"}}
+
+	// When
+	serialized := serializeErrors(fields)
+
+	// Then
+	serialized["added"] = true
+	if _, ok := fields["added"]; !ok {
+		t.Errorf("serializeErrors copied fields without errors")
+	}
+}
+
+func TestSerializeErrorsDoesNotModifyCallerFields(t *testing.T) {
+	// Given
+	err := errors.New("failure")
+	fields := map[string]any{"error": err, "items": []any{err}}
+
+	// When
+	serializeErrors(fields)
+
+	// Then
+	if fields["error"] != err {
+		t.Errorf("Field error was modified: %v", fields["error"])
+	}
+	if items := fields["items"].([]any); items[0] != err {
+		t.Errorf("Field items was modified: %v", items)
+	}
+}
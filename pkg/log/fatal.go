@@ -0,0 +1,132 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fatalHookTimeout bounds how long FATAL entries wait for the registered
+// hooks before the program exits
+const fatalHookTimeout = 5 * time.Second
+
+var (
+	// fatalHooks are run, most recently registered first, before a FATAL
+	// entry exits the program
+	fatalHooksMu sync.Mutex
+	fatalHooks   []func()
+
+	// exitFunc terminates the program after a FATAL entry
+	exitFunc atomic.Pointer[func(code int)]
+)
+
+// RegisterFatalHook registers a function that is run before a FATAL entry
+// exits the program, such as closing database connections. os.Exit skips
+// deferred calls, so cleanup that must happen on fatal errors belongs here:
+//
+//	db, err := postgres.New(ctx, cfg)
+//	if err != nil {
+//	    log.Fatalf("Failed to connect to database", map[string]any{"error": err})
+//	}
+//	log.RegisterFatalHook(db.Close)
+//
+// Hooks run in reverse order of registration, like deferred calls. A hook
+// that panics does not prevent the others from running, and the program
+// exits once all hooks return or after 5 seconds.
+func RegisterFatalHook(hook func()) {
+	fatalHooksMu.Lock()
+	defer fatalHooksMu.Unlock()
+	fatalHooks = append(fatalHooks, hook)
+}
+
+// SetExitFunc replaces the function that terminates the program after a
+// FATAL entry, which is os.Exit by default. Passing nil restores os.Exit.
+//
+// Tests can use it to assert fatal paths without exiting; the Fatal
+// functions then return once the replacement returns:
+//
+//	var code int
+//	log.SetExitFunc(func(c int) { code = c })
+//	defer log.SetExitFunc(nil)
+func SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		exitFunc.Store(nil)
+		return
+	}
+	exitFunc.Store(&fn)
+}
+
+// exit runs the fatal hooks, writes queued entries and terminates the
+// program with the given code
+func (l *jsonLogger) exit(code int) {
+	runFatalHooks(fatalHookTimeout)
+
+	// Hooks may log, so flush after they have run
+	_ = l.flush()
+
+	if fn := exitFunc.Load(); fn != nil {
+		(*fn)(code)
+		return
+	}
+	os.Exit(code)
+}
+
+// runFatalHooks runs the registered hooks in reverse order, waiting at most
+// timeout for them to return
+func runFatalHooks(timeout time.Duration) {
+	fatalHooksMu.Lock()
+	hooks := append([]func(){}, fatalHooks...)
+	fatalHooksMu.Unlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := len(hooks) - 1; i >= 0; i-- {
+			runFatalHook(hooks[i])
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		fmt.Fprintf(os.Stderr, "Warning: fatal hooks did not finish within %s\n", timeout)
+	}
+}
+
+// runFatalHook runs a hook, reporting a panic instead of propagating it
+func runFatalHook(hook func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "Error running fatal hook: %v\n", r)
+		}
+	}()
+	hook()
+}
+
+// PanicError is the value Panicf and PanicCtx panic with. It carries the
+// entry's message and fields, so a recovering handler can inspect them:
+//
+//	defer func() {
+//	    if r := recover(); r != nil {
+//	        var panicErr *log.PanicError
+//	        if err, ok := r.(error); ok && errors.As(err, &panicErr) {
+//	            // panicErr.Fields["order_id"] ...
+//	        }
+//	    }
+//	}()
+type PanicError struct {
+	Message string
+	Fields  map[string]any
+}
+
+// Error returns the message. Fields are omitted so that sensitive values do
+// not end up in crash output.
+func (e *PanicError) Error() string {
+	return e.Message
+}
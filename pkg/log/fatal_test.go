@@ -0,0 +1,143 @@
+package log
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// resetFatalHooks removes the hooks registered by a test
+func resetFatalHooks(t *testing.T) {
+	t.Helper()
+
+	fatalHooksMu.Lock()
+	orig := fatalHooks
+	fatalHooks = nil
+	fatalHooksMu.Unlock()
+
+	t.Cleanup(func() {
+		fatalHooksMu.Lock()
+		fatalHooks = orig
+		fatalHooksMu.Unlock()
+	})
+}
+
+func TestFatalRunsHooksThenExitFunc(t *testing.T) {
+	// Given
+	resetFatalHooks(t)
+	buf := &lockedBuffer{}
+	logger := New(Options{Output: buf})
+
+	var calls []string
+	RegisterFatalHook(func() { calls = append(calls, "close database") })
+	RegisterFatalHook(func() { panic("hook failure") })
+	RegisterFatalHook(func() { calls = append(calls, "close redis") })
+
+	exitCode := -1
+	SetExitFunc(func(code int) {
+		calls = append(calls, "exit")
+		exitCode = code
+	})
+	defer SetExitFunc(nil)
+
+	// When
+	logger.Fatalf("cannot start", map[string]any{"reason": "config"})
+
+	// Then - hooks run in reverse order and a panicking hook is skipped
+	expected := []string{"close redis", "close database", "exit"}
+	if !slices.Equal(calls, expected) {
+		t.Errorf("Calls = %v, want %v", calls, expected)
+	}
+	if exitCode != 1 {
+		t.Errorf("Exit code = %d, want 1", exitCode)
+	}
+
+	entries := buf.entries(t)
+	if len(entries) != 1 || entries[0].Level != "FATAL" {
+		t.Errorf("Got entries %+v, want one FATAL entry", entries)
+	}
+}
+
+func TestFatalHooksAreBoundedByTimeout(t *testing.T) {
+	// Given
+	resetFatalHooks(t)
+	block := make(chan struct{})
+	defer close(block)
+	RegisterFatalHook(func() { <-block })
+
+	// When
+	start := time.Now()
+	runFatalHooks(20 * time.Millisecond)
+
+	// Then
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runFatalHooks took %s, want it to give up after the timeout", elapsed)
+	}
+}
+
+func TestPanicfPanicsWithStructuredError(t *testing.T) {
+	testCases := []struct {
+		name          string
+		level         string
+		expectedEntry bool
+	}{
+		{
+			name:          "entry logged",
+			level:         "info",
+			expectedEntry: true,
+		},
+		{
+			name:          "panics even when the level is filtered",
+			level:         "fatal",
+			expectedEntry: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			buf := &lockedBuffer{}
+			logger := New(Options{Output: buf, Level: tc.level})
+			fields := map[string]any{"order_id": "A-1"}
+
+			// When
+			var recovered any
+			func() {
+				defer func() { recovered = recover() }()
+				logger.Panicf("invariant violated", fields)
+			}()
+
+			// Then
+			err, ok := recovered.(error)
+			var panicErr *PanicError
+			if !ok || !errors.As(err, &panicErr) {
+				t.Fatalf("Recovered %v, want a *PanicError", recovered)
+			}
+			if panicErr.Message != "invariant violated" || panicErr.Fields["order_id"] != "A-1" {
+				t.Errorf("PanicError = %+v, want the message and fields", panicErr)
+			}
+
+			entries := buf.entries(t)
+			if got := len(entries) == 1 && entries[0].Level == "PANIC"; got != tc.expectedEntry {
+				t.Errorf("Got entries %+v, want a PANIC entry: %v", entries, tc.expectedEntry)
+			}
+		})
+	}
+}
+
+func TestPanicLevelIsParsedAndOrdered(t *testing.T) {
+	// Given
+	level, ok := lookupLevel("panic")
+
+	// Then
+	if !ok || level != paniclevel {
+		t.Fatalf("lookupLevel(panic) = %s, %v, want PANIC", level, ok)
+	}
+	if !levelEnabled(fatallevel, paniclevel) || levelEnabled(errorlevel, paniclevel) {
+		t.Errorf("PANIC is not ordered between ERROR and FATAL")
+	}
+	if toSlogLevel(paniclevel) != LevelPanic || fromSlogLevel(LevelPanic) != errorlevel {
+		t.Errorf("PANIC does not map to LevelPanic, or slog records above ERROR are not capped")
+	}
+}
@@ -0,0 +1,210 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loggerNameField is the field that records the name of a named logger
+const loggerNameField = "logger"
+
+// Named returns a child logger whose name is the parent's name and name
+// joined with a dot, such as "db.pool". The name is added to entries as the
+// "logger" field, and selects the level overrides set with SetLevelOverride.
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{core: l.logger().named(name)}
+}
+
+// named derives a child logger with the given name appended to the parent's
+func (l *jsonLogger) named(name string) *jsonLogger {
+	if name == "" {
+		return l
+	}
+
+	fullName := groupKey(l.name, name)
+	child := l.with(map[string]any{loggerNameField: fullName})
+	child.name = fullName
+	return child
+}
+
+// SetLevelFor sets the minimum level of the default logger for ttl, after
+// which the previous level is restored. Calling SetLevel or SetLevelFor
+// before then replaces the temporary level.
+func SetLevelFor(level string, ttl time.Duration) {
+	defaultLogger.Load().level.set("", parseLevel(level), ttl)
+}
+
+// SetLevelOverride sets the minimum level of the named loggers derived from
+// the default logger, overriding its level. An override for "db" also
+// applies to "db.pool", unless it has an override of its own. Passing an
+// empty level removes the override.
+func SetLevelOverride(name, level string) {
+	var parsed logLevel
+	if level != "" {
+		parsed = parseLevel(level)
+	}
+	defaultLogger.Load().level.set(name, parsed, 0)
+}
+
+// LevelHandler returns an http.Handler that reports and changes the level of
+// the default logger at runtime, as described for Logger.LevelHandler
+func LevelHandler() http.Handler {
+	return &levelHandler{logger: func() *jsonLogger { return defaultLogger.Load() }}
+}
+
+// GinLevelHandler returns LevelHandler as a gin.HandlerFunc
+func GinLevelHandler() gin.HandlerFunc {
+	return gin.WrapH(LevelHandler())
+}
+
+// LevelHandler returns an http.Handler that reports and changes the level of
+// the logger and the loggers derived from it at runtime.
+//
+// GET responds with the current level and overrides:
+//
+//	{"level":"INFO","overrides":{"db":{"level":"DEBUG","revert_at":"2024-04-22T15:09:05Z"}}}
+//
+// PUT changes the level, or the override of the named logger, and responds
+// with the new state. A ttl makes the change temporary, and an empty level
+// with a logger removes its override:
+//
+//	{"level":"debug"}
+//	{"level":"debug","ttl":"5m"}
+//	{"level":"debug","logger":"db","ttl":"5m"}
+//	{"level":"","logger":"db"}
+//
+// Invalid bodies are rejected with 400, and bodies over 1 KiB with 413.
+//
+// The handler changes the logging of the whole process, so it must only be
+// exposed on internal or authenticated routes:
+//
+//	admin := router.Group("/admin", auth)
+//	admin.Any("/log/level", gin.WrapH(log.Default().LevelHandler()))
+func (l *Logger) LevelHandler() http.Handler {
+	return &levelHandler{logger: l.logger}
+}
+
+// levelHandler serves the level of a logger over HTTP
+type levelHandler struct {
+	logger func() *jsonLogger
+}
+
+// maxLevelRequestBytes limits the size of the body of a PUT request
+const maxLevelRequestBytes = 1 << 10
+
+// levelRequest is the body of a PUT request
+type levelRequest struct {
+	Level  *string `json:"level"`
+	Logger string  `json:"logger"`
+	TTL    string  `json:"ttl"`
+}
+
+// levelState reports a level and when it reverts
+type levelState struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// levelResponse is the state reported by the handler
+type levelResponse struct {
+	levelState
+	Overrides map[string]levelState `json:"overrides,omitempty"`
+}
+
+// ServeHTTP implements http.Handler
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	levels := h.logger().level
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		r.Body = http.MaxBytesReader(w, r.Body, maxLevelRequestBytes)
+		if err := applyLevelRequest(levels, r); err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeLevelJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	writeLevelJSON(w, http.StatusOK, newLevelResponse(levels))
+}
+
+// applyLevelRequest validates the request body and changes the level
+func applyLevelRequest(levels *levelVar, r *http.Request) error {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if req.Level == nil {
+		return errors.New("level is required")
+	}
+
+	var level logLevel
+	if *req.Level != "" || req.Logger == "" {
+		parsed, ok := lookupLevel(*req.Level)
+		if !ok {
+			return fmt.Errorf("unknown level %q", *req.Level)
+		}
+		level = parsed
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid ttl %q", req.TTL)
+		}
+		ttl = parsed
+	}
+
+	levels.set(req.Logger, level, ttl)
+	return nil
+}
+
+// newLevelResponse reports the current level and overrides
+func newLevelResponse(levels *levelVar) levelResponse {
+	resp := levelResponse{levelState: newLevelState(levels, "", levels.Load())}
+
+	if overrides := levels.overrides.Load(); overrides != nil {
+		resp.Overrides = make(map[string]levelState, len(*overrides))
+		for name, level := range *overrides {
+			resp.Overrides[name] = newLevelState(levels, name, level)
+		}
+	}
+
+	return resp
+}
+
+// newLevelState reports a level and its pending revert, if any
+func newLevelState(levels *levelVar, name string, level logLevel) levelState {
+	state := levelState{Level: string(level)}
+	if at := levels.revertAt(name); !at.IsZero() {
+		at = at.UTC().Truncate(time.Second)
+		state.RevertAt = &at
+	}
+	return state
+}
+
+// writeLevelJSON writes v as the JSON response body
+func writeLevelJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing level response: %v\n", err)
+	}
+}
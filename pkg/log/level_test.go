@@ -0,0 +1,298 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNamedLoggersUseLevelOverrides(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{Output: buf, Level: "info"})
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	cache := logger.Named("cache")
+
+	// When
+	logger.core.level.set("db", debuglevel, 0)
+	logger.core.level.set("db.pool", errorlevel, 0)
+	db.Debugf("db debug", nil)
+	pool.Warnf("pool warn", nil)
+	pool.Errorf("pool error", nil)
+	cache.Debugf("cache debug", nil)
+
+	// Then
+	entries := buf.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Message != "db debug" || (*entries[0].Fields)["logger"] != "db" {
+		t.Errorf("First entry = %+v, want db debug from logger db", entries[0])
+	}
+	if entries[1].Message != "pool error" || (*entries[1].Fields)["logger"] != "db.pool" {
+		t.Errorf("Second entry = %+v, want pool error from logger db.pool", entries[1])
+	}
+}
+
+func TestSetLevelOverrideAppliesToDefaultLogger(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &lockedBuffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	worker := Default().Named("worker")
+
+	// When
+	SetLevelOverride("worker", "debug")
+	worker.Debugf("with override", nil)
+	SetLevelOverride("worker", "")
+	worker.Debugf("without override", nil)
+
+	// Then
+	entries := buf.entries(t)
+	if len(entries) != 1 || entries[0].Message != "with override" {
+		t.Errorf("Got %+v, want only the entry logged with the override", entries)
+	}
+}
+
+// testClock replaces the clock and timers of a levelVar, so temporary levels
+// revert when the test advances time
+type testClock struct {
+	now    time.Time
+	timers []*testTimer
+}
+
+type testTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func newTestClock(levels *levelVar) *testClock {
+	c := &testClock{now: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	levels.now = func() time.Time { return c.now }
+	levels.afterFunc = func(d time.Duration, f func()) func() bool {
+		timer := &testTimer{at: c.now.Add(d), f: f}
+		c.timers = append(c.timers, timer)
+		return func() bool {
+			stopped := timer.stopped
+			timer.stopped = true
+			return !stopped
+		}
+	}
+	return c
+}
+
+// advance moves the clock forward and fires the timers that are due
+func (c *testClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	for _, timer := range c.timers {
+		if !timer.stopped && !timer.at.After(c.now) {
+			timer.stopped = true
+			timer.f()
+		}
+	}
+}
+
+func TestTemporaryLevelRevertsAfterTTL(t *testing.T) {
+	// Given
+	levels := newLevelVar(infolevel)
+	clock := newTestClock(levels)
+
+	// When
+	levels.set("", debuglevel, 20*time.Millisecond)
+	levels.set("", tracelevel, 20*time.Millisecond)
+	levels.set("db", debuglevel, 20*time.Millisecond)
+
+	// Then - both elevations revert to the level set before the first one
+	if levels.Load() != tracelevel || levels.For("db") != debuglevel {
+		t.Fatalf("Levels = %s and %s, want TRACE and DEBUG", levels.Load(), levels.For("db"))
+	}
+	if at := levels.revertAt(""); !at.Equal(clock.now.Add(20 * time.Millisecond)) {
+		t.Errorf("Revert at %v, want 20ms from now", at)
+	}
+
+	clock.advance(19 * time.Millisecond)
+	if levels.Load() != tracelevel {
+		t.Errorf("Level = %s before TTL, want TRACE", levels.Load())
+	}
+
+	clock.advance(time.Millisecond)
+
+	if levels.Load() != infolevel {
+		t.Errorf("Level = %s after TTL, want INFO", levels.Load())
+	}
+	if levels.For("db") != infolevel || levels.overrides.Load() != nil {
+		t.Errorf("Override for db was not removed after TTL")
+	}
+}
+
+func TestPermanentLevelCancelsRevert(t *testing.T) {
+	// Given
+	levels := newLevelVar(infolevel)
+	clock := newTestClock(levels)
+	levels.set("", debuglevel, 10*time.Millisecond)
+
+	// When
+	levels.Store(warnlevel)
+	clock.advance(30 * time.Millisecond)
+
+	// Then
+	if levels.Load() != warnlevel {
+		t.Errorf("Level = %s, want WARN", levels.Load())
+	}
+	if !levels.revertAt("").IsZero() {
+		t.Errorf("Revert is still pending")
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expectedLevel  string
+		expectedDB     string
+		expectRevert   bool
+	}{
+		{
+			name:           "get reports current level",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedLevel:  "INFO",
+		},
+		{
+			name:           "put changes level",
+			method:         http.MethodPut,
+			body:           `{"level":"debug"}`,
+			expectedStatus: http.StatusOK,
+			expectedLevel:  "DEBUG",
+		},
+		{
+			name:           "put with ttl is temporary",
+			method:         http.MethodPut,
+			body:           `{"level":"trace","ttl":"5m"}`,
+			expectedStatus: http.StatusOK,
+			expectedLevel:  "TRACE",
+			expectRevert:   true,
+		},
+		{
+			name:           "put with logger sets override",
+			method:         http.MethodPut,
+			body:           `{"level":"error","logger":"db"}`,
+			expectedStatus: http.StatusOK,
+			expectedLevel:  "INFO",
+			expectedDB:     "ERROR",
+		},
+		{
+			name:           "unknown level is rejected",
+			method:         http.MethodPut,
+			body:           `{"level":"verbose"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid ttl is rejected",
+			method:         http.MethodPut,
+			body:           `{"level":"debug","ttl":"-1m"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing level is rejected",
+			method:         http.MethodPut,
+			body:           `{"logger":"db"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "oversized body is rejected",
+			method:         http.MethodPut,
+			body:           `{"level":"debug","logger":"` + strings.Repeat("a", 2<<10) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "other methods are not allowed",
+			method:         http.MethodDelete,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			logger := New(Options{Output: &bytes.Buffer{}})
+			handler := logger.LevelHandler()
+
+			// When
+			req := httptest.NewRequest(tc.method, "/log/level", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			// Then
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("Status = %d, want %d: %s", rec.Code, tc.expectedStatus, rec.Body.String())
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Level     string     `json:"level"`
+				RevertAt  *time.Time `json:"revert_at"`
+				Overrides map[string]struct {
+					Level string `json:"level"`
+				} `json:"overrides"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if resp.Level != tc.expectedLevel {
+				t.Errorf("Level = %s, want %s", resp.Level, tc.expectedLevel)
+			}
+			if resp.Overrides["db"].Level != tc.expectedDB {
+				t.Errorf("Override for db = %q, want %q", resp.Overrides["db"].Level, tc.expectedDB)
+			}
+			if (resp.RevertAt != nil) != tc.expectRevert {
+				t.Errorf("RevertAt = %v, want set: %v", resp.RevertAt, tc.expectRevert)
+			}
+			if got := string(logger.core.level.Load()); got != tc.expectedLevel {
+				t.Errorf("Logger level = %s, want %s", got, tc.expectedLevel)
+			}
+		})
+	}
+}
+
+func TestGinLevelHandlerControlsDefaultLogger(t *testing.T) {
+	// Given
+	gin.SetMode(gin.TestMode)
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+	defaultLogger.Store(newJSONLogger(infolevel))
+
+	router := gin.New()
+	router.Any("/log/level", GinLevelHandler())
+
+	// When
+	req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"warn"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	// Then
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if level := defaultLogger.Load().level.Load(); level != warnlevel {
+		t.Errorf("Default logger level = %s, want WARN", level)
+	}
+}
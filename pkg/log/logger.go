@@ -1,43 +1,213 @@
 package log
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
 // Default global logger instance
-var defaultLogger *jsonLogger
+var defaultLogger atomic.Pointer[jsonLogger]
 
 func init() {
 	// Initialize the default logger with INFO level
-	defaultLogger = newJSONLogger(infolevel)
+	defaultLogger.Store(newJSONLogger(infolevel))
 }
 
 // newJSONLogger creates a new logger with the specified level
 func newJSONLogger(level logLevel) *jsonLogger {
-	return &jsonLogger{
-		out:    os.Stdout,
-		level:  level,
-		fields: make(map[string]any),
+	l := &jsonLogger{
+		out:        newSyncWriter(os.Stdout),
+		level:      newLevelVar(level),
+		handler:    &handlerVar{},
+		sampler:    &samplerVar{},
+		redactor:   &redactorVar{},
+		encoder:    &encoderVar{},
+		sinks:      &sinksVar{},
+		caller:     &atomic.Bool{},
+		stacktrace: &atomic.Bool{},
+	}
+
+	l.redactor.Store(DefaultRedactor())
+	l.encoder.Store(defaultEncoder())
+	l.fields.Store(&map[string]any{})
+	return l
+}
+
+// New creates a logger configured with the given options
+func New(opts Options) *Logger {
+	level := infolevel
+	if opts.Level != "" {
+		level = parseLevel(opts.Level)
+	}
+
+	core := newJSONLogger(level)
+	if opts.Output != nil {
+		core.out.SetOutput(opts.Output)
+	}
+
+	if opts.Handler != nil {
+		core.handler.Store(opts.Handler)
+	}
+
+	if opts.Encoder != nil {
+		core.encoder.Store(opts.Encoder)
+	} else if opts.Format != "" {
+		if enc, ok := parseFormat(opts.Format); ok {
+			core.encoder.Store(enc)
+		}
+	}
+
+	if opts.Redactor != nil {
+		core.redactor.Store(opts.Redactor)
+	}
+
+	if opts.Sampling != nil {
+		core.sampler.Store(newSampler(core, *opts.Sampling))
+	}
+
+	if opts.Async != nil {
+		core.out.SetAsync(opts.Async)
+	}
+
+	if len(opts.Sinks) > 0 {
+		core.sinks.Store(newSinks(opts.Sinks))
+	}
+
+	core.caller.Store(opts.AddCaller)
+	core.stacktrace.Store(opts.StackTrace)
+	core.setFields(opts.Fields)
+	if opts.Name != "" {
+		core = core.named(opts.Name)
+	}
+	return &Logger{core: core}
+}
+
+// With returns a child logger that includes the given fields in every entry.
+// The parent logger is left unchanged, and fields passed to With override
+// parent fields with the same key. The child shares the parent's level and
+// output, so changing either on the parent also applies to the child.
+func (l *Logger) With(fields map[string]any) *Logger {
+	return &Logger{core: l.logger().with(fields)}
+}
+
+// logger returns the core of l, or the core of the default logger when l is
+// nil or a zero Logger
+func (l *Logger) logger() *jsonLogger {
+	if l == nil || l.core == nil {
+		return defaultLogger.Load()
+	}
+	return l.core
+}
+
+// with derives a new jsonLogger with a copy of the parent's fields merged
+// with the given fields
+func (l *jsonLogger) with(fields map[string]any) *jsonLogger {
+	child := &jsonLogger{
+		out:        l.out,
+		level:      l.level,
+		handler:    l.handler,
+		sampler:    l.sampler,
+		redactor:   l.redactor,
+		encoder:    l.encoder,
+		sinks:      l.sinks,
+		caller:     l.caller,
+		stacktrace: l.stacktrace,
+		name:       l.name,
+	}
+
+	child.initialized.Store(l.initialized.Load())
+	child.fields.Store(mergeFields(l.getFields(), fields))
+	return child
+}
+
+// getFields returns the logger's current fields. The returned map must not
+// be modified.
+func (l *jsonLogger) getFields() map[string]any {
+	return *l.fields.Load()
+}
+
+// setFields adds fields to the logger by swapping in a new merged map, so
+// concurrent log calls keep reading the map they already loaded
+func (l *jsonLogger) setFields(fields map[string]any) {
+	for {
+		current := l.fields.Load()
+		if l.fields.CompareAndSwap(current, mergeFields(*current, fields)) {
+			return
+		}
+	}
+}
+
+// mergeFields returns a new map containing base overridden by overrides
+func mergeFields(base, overrides map[string]any) *map[string]any {
+	merged := make(map[string]any, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range overrides {
+		merged[k] = v
+	}
+
+	return &merged
+}
+
+// parseLevel converts a level name to a logLevel, defaulting to INFO
+func parseLevel(level string) logLevel {
+	parsed, ok := lookupLevel(level)
+	if !ok {
+		// Default to INFO if invalid level
+		fmt.Fprintf(os.Stderr, "Warning: Unknown log level '%s', defaulting to INFO\n", level)
+		return infolevel
+	}
+	return parsed
+}
+
+// lookupLevel converts a level name to a logLevel, reporting whether the
+// name is known
+func lookupLevel(level string) (logLevel, bool) {
+	// Case-insensitive matching of log level strings
+	switch level {
+	case "TRACE", "trace":
+		return tracelevel, true
+	case "DEBUG", "debug":
+		return debuglevel, true
+	case "INFO", "info":
+		return infolevel, true
+	case "WARN", "warn", "WARNING", "warning":
+		return warnlevel, true
+	case "ERROR", "error":
+		return errorlevel, true
+	case "PANIC", "panic":
+		return paniclevel, true
+	case "FATAL", "fatal":
+		return fatallevel, true
+	default:
+		return "", false
 	}
 }
 
 // shouldLog determines if a message at the given level should be logged
 func (l *jsonLogger) shouldLog(level logLevel) bool {
-	// Mapping log levels to numeric values for comparison
-	levelValues := map[logLevel]int{
-		tracelevel: 0,
-		debuglevel: 1,
-		infolevel:  2,
-		warnlevel:  3,
-		errorlevel: 4,
-		fatallevel: 5,
-	}
+	return levelEnabled(level, l.level.For(l.name))
+}
 
+// levelValues maps log levels to numeric values for comparison
+var levelValues = map[logLevel]int{
+	tracelevel: 0,
+	debuglevel: 1,
+	infolevel:  2,
+	warnlevel:  3,
+	errorlevel: 4,
+	paniclevel: 5,
+	fatallevel: 6,
+}
+
+// levelEnabled reports whether a message at level passes the minimum level
+func levelEnabled(level, minLevel logLevel) bool {
 	// Get numeric values of the configured and message levels
-	configuredValue, configExists := levelValues[l.level]
+	configuredValue, configExists := levelValues[minLevel]
 	messageValue, messageExists := levelValues[level]
 
 	// Default to showing the message if levels are unknown
@@ -56,17 +226,38 @@ func (l *jsonLogger) log(level logLevel, msg string, fields map[string]any) {
 		return // Skip logging this message
 	}
 
+	// Drop the entry if it exceeds the sampling limits for its message
+	if s := l.sampler.Load(); s != nil && !s.sample(level, msg) {
+		return
+	}
+
+	// Record where the entry was logged from, if enabled
+	if captured := l.captureFields(level); captured != nil {
+		fields = *mergeFields(fields, captured)
+	}
+
+	l.write(level, msg, fields)
+
+	// Exit even if the entry could not be written
+	if level == fatallevel {
+		l.exit(1)
+	}
+}
+
+// write merges the logger's fields into the entry and outputs it
+func (l *jsonLogger) write(level logLevel, msg string, fields map[string]any) {
 	// Create merged fields with app fields
 	var mergedFields map[string]any
+	appFields := l.getFields()
 
 	// Only allocate map if we have fields to merge
 	// Fixed S1009: Removed unnecessary nil check since len() for nil maps is defined as zero
-	if len(l.fields) > 0 || len(fields) > 0 {
-		mapSize := len(l.fields) + len(fields)
+	if len(appFields) > 0 || len(fields) > 0 {
+		mapSize := len(appFields) + len(fields)
 		mergedFields = make(map[string]any, mapSize)
 
 		// Add app fields first
-		for k, v := range l.fields {
+		for k, v := range appFields {
 			mergedFields[k] = v
 		}
 
@@ -77,31 +268,48 @@ func (l *jsonLogger) log(level logLevel, msg string, fields map[string]any) {
 		}
 	}
 
-	entry := logEntry{
-		Timestamp: time.Now().Format(time.RFC3339),
-		Level:     string(level),
-		Message:   msg,
+	// Mask sensitive values before the entry is marshaled. mergedFields is
+	// owned by this call, so it can be redacted in place.
+	if r := l.redactor.Load(); r != nil && len(mergedFields) > 0 {
+		r.redactFields(mergedFields)
 	}
 
-	// Fixed S1009: Removed unnecessary nil check
-	if len(mergedFields) > 0 {
-		entry.Fields = &mergedFields
+	// Route the entry to the slog handler if one is configured
+	if handler := l.handler.Load(); handler != nil {
+		if err := handleRecord(handler, level, msg, mergedFields); err != nil {
+			fmt.Fprintf(os.Stderr, "Error handling log entry: %v\n", err)
+		}
+		return
 	}
 
-	jsonData, err := json.Marshal(entry)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling log entry: %v\n", err)
+	l.writeEntry(level, msg, mergedFields)
+}
+
+// writeEntry encodes the entry with the logger's encoder and writes it to
+// the output, or to the sinks if any are set
+func (l *jsonLogger) writeEntry(level logLevel, msg string, fields map[string]any) {
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   string(level),
+		Message: msg,
+		Fields:  fields,
+	}
+
+	// Route the entry to the sinks instead of the output if any are set
+	if sinks := l.sinks.Load(); len(sinks) > 0 {
+		l.writeSinks(sinks, level, entry)
 		return
 	}
 
-	// Fixed errcheck: Now checking error from fmt.Fprintln
-	if _, err := fmt.Fprintln(l.out, string(jsonData)); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing log entry: %v\n", err)
+	data, err := l.encoder.Load().Encode(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling log entry: %v\n", err)
 		return
 	}
 
-	// If fatal, exit the program
-	if level == fatallevel {
-		os.Exit(1)
+	// Write the entry and its newline in a single call so concurrent
+	// entries are never interleaved
+	if _, err := l.out.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing log entry: %v\n", err)
 	}
 }
@@ -90,7 +90,7 @@ func TestLoggerOutputsCorrectJsonFormat(t *testing.T) {
 				// Given
 				buf := &bytes.Buffer{}
 				logger := newJSONLogger(tracelevel)
-				logger.out = buf
+				logger.out.SetOutput(buf)
 
 				// When
 				logger.log(tc.level, tc.message, tc.fields)
@@ -121,14 +121,14 @@ func TestFieldMergingPrioritizesLogFieldsOverGlobals(t *testing.T) {
 	// Given
 	buf := &bytes.Buffer{}
 	logger := newJSONLogger(infolevel)
-	logger.out = buf
+	logger.out.SetOutput(buf)
 
 	// Global fields
-	logger.fields = map[string]any{
+	logger.setFields(map[string]any{
 		"app":      "test-app",
 		"version":  "1.0.0",
 		"conflict": "global-value", // This should be overridden
-	}
+	})
 
 	// When - Log with fields that include an override
 	logFields := map[string]any{
@@ -167,8 +167,8 @@ func TestFieldMergingPrioritizesLogFieldsOverGlobals(t *testing.T) {
 
 func TestAllLogLevelFunctionsProduceCorrectOutput(t *testing.T) {
 	// Given
-	origLogger := defaultLogger
-	defer func() { defaultLogger = origLogger }()
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
 
 	tests := []struct {
 		name    string
@@ -194,11 +194,11 @@ func TestAllLogLevelFunctionsProduceCorrectOutput(t *testing.T) {
 			// Use a fresh buffer and logger for each test
 			buf := &bytes.Buffer{}
 			testLogger := newJSONLogger(tracelevel)
-			testLogger.out = buf
+			testLogger.out.SetOutput(buf)
 
 			// Critical section: update the global logger
 			mutex.Lock()
-			defaultLogger = testLogger
+			defaultLogger.Store(testLogger)
 			mutex.Unlock()
 
 			// When
@@ -239,15 +239,15 @@ func TestAllLogLevelFunctionsProduceCorrectOutput(t *testing.T) {
 
 func TestSetOutputChangesDestination(t *testing.T) {
 	// Given
-	origLogger := defaultLogger
-	defer func() { defaultLogger = origLogger }()
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
 
 	buf1 := &bytes.Buffer{}
 	buf2 := &bytes.Buffer{}
 
 	testLogger := newJSONLogger(infolevel)
-	testLogger.out = buf1
-	defaultLogger = testLogger
+	testLogger.out.SetOutput(buf1)
+	defaultLogger.Store(testLogger)
 
 	// When
 	Infof("message to first buffer", nil)
@@ -283,13 +283,13 @@ func TestSetOutputChangesDestination(t *testing.T) {
 
 func TestSetFieldsAddsGlobalFields(t *testing.T) {
 	// Given
-	origLogger := defaultLogger
-	defer func() { defaultLogger = origLogger }()
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
 
 	buf := &bytes.Buffer{}
 	testLogger := newJSONLogger(infolevel)
-	testLogger.out = buf
-	defaultLogger = testLogger
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
 
 	// When
 	SetFields(map[string]any{
@@ -326,8 +326,8 @@ func TestSetFieldsAddsGlobalFields(t *testing.T) {
 
 func TestSetLevelFiltersMessages(t *testing.T) {
 	// Given
-	origLogger := defaultLogger
-	defer func() { defaultLogger = origLogger }()
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
 
 	// Test cases for different log levels
 	testCases := []struct {
@@ -429,8 +429,8 @@ func TestSetLevelFiltersMessages(t *testing.T) {
 
 			// Create a new logger and set it as default
 			testLogger := newJSONLogger(infolevel)
-			testLogger.out = buf
-			defaultLogger = testLogger
+			testLogger.out.SetOutput(buf)
+			defaultLogger.Store(testLogger)
 
 			// Set the log level using the public function
 			SetLevel(tc.setLevel)
@@ -481,8 +481,8 @@ func TestSetLevelFiltersMessages(t *testing.T) {
 // Test case insensitivity in SetLevel
 func TestSetLevelCaseInsensitivity(t *testing.T) {
 	// Given
-	origLogger := defaultLogger
-	defer func() { defaultLogger = origLogger }()
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
 
 	testCases := []struct {
 		input         string
@@ -511,10 +511,138 @@ func TestSetLevelCaseInsensitivity(t *testing.T) {
 			SetLevel(tc.input)
 
 			// Then
-			if defaultLogger.level != tc.expectedLevel {
+			if level := defaultLogger.Load().level.Load(); level != tc.expectedLevel {
 				t.Errorf("SetLevel(%q) set level to %q, want %q",
-					tc.input, defaultLogger.level, tc.expectedLevel)
+					tc.input, level, tc.expectedLevel)
 			}
 		})
 	}
 }
+
+func TestNewAppliesOptions(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{
+		Level:  "WARN",
+		Output: buf,
+		Fields: map[string]any{"service": "api"},
+	})
+
+	// When
+	logger.Infof("filtered message", nil)
+	logger.Warnf("logged message", nil)
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	if entry.Message != "logged message" {
+		t.Errorf("Message = %s, want %s", entry.Message, "logged message")
+	}
+
+	if entry.Fields == nil || (*entry.Fields)["service"] != "api" {
+		t.Errorf("Expected service field to be present, got %+v", entry.Fields)
+	}
+}
+
+func TestWithDerivesChildWithoutModifyingParent(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	parent := New(Options{
+		Output: buf,
+		Fields: map[string]any{"service": "api", "conflict": "parent-value"},
+	})
+
+	// When
+	child := parent.With(map[string]any{"request_id": "req-123", "conflict": "child-value"})
+	child.Infof("child message", nil)
+	parent.Infof("parent message", nil)
+
+	// Then
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var childEntry, parentEntry testLogEntry
+	if err := json.Unmarshal([]byte(lines[0]), &childEntry); err != nil {
+		t.Fatalf("Failed to unmarshal child entry: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &parentEntry); err != nil {
+		t.Fatalf("Failed to unmarshal parent entry: %v", err)
+	}
+
+	childFields := *childEntry.Fields
+	if childFields["service"] != "api" || childFields["request_id"] != "req-123" || childFields["conflict"] != "child-value" {
+		t.Errorf("Unexpected child fields: %+v", childFields)
+	}
+
+	parentFields := *parentEntry.Fields
+	if _, exists := parentFields["request_id"]; exists {
+		t.Errorf("Child fields leaked into parent: %+v", parentFields)
+	}
+	if parentFields["conflict"] != "parent-value" {
+		t.Errorf("Field conflict = %v, want %v", parentFields["conflict"], "parent-value")
+	}
+}
+
+func TestSetDefaultReplacesPackageLogger(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, Fields: map[string]any{"app": "test-app"}})
+
+	// When
+	SetDefault(logger)
+	With(map[string]any{"local": "value"}).Infof("test message", nil)
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	fields := *entry.Fields
+	if fields["app"] != "test-app" || fields["local"] != "value" {
+		t.Errorf("Unexpected fields: %+v", fields)
+	}
+
+	if Default().core != logger.core {
+		t.Error("Default() should return the logger passed to SetDefault")
+	}
+}
+
+func TestNilAndZeroLoggersUseDefault(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	SetDefault(New(Options{Output: buf}))
+
+	// When
+	SetDefault(nil)
+	SetDefault(&Logger{})
+
+	var nilLogger *Logger
+	nilLogger.Infof("from nil logger", nil)
+	(&Logger{}).With(map[string]any{"zero": true}).Infof("from zero logger", nil)
+
+	// Then
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries on the default logger, got %d\nOutput was: %s", len(lines), buf.String())
+	}
+
+	var entry testLogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+	if entry.Message != "from zero logger" || (*entry.Fields)["zero"] != true {
+		t.Errorf("Unexpected entry: %s", lines[1])
+	}
+}
@@ -0,0 +1,33 @@
+// Package logtest captures log entries in memory so tests can assert on what
+// was logged without redirecting output and parsing JSON lines.
+//
+// Basic Usage:
+//
+//	func TestCreateUser(t *testing.T) {
+//	    rec := logtest.New(t)
+//
+//	    service.CreateUser(ctx, user)
+//
+//	    rec.AssertContains("INFO", "User created", map[string]any{"user_id": "42"})
+//	    rec.AssertCount("ERROR", 0)
+//	}
+//
+// New replaces the default pkg/log logger and log/slog's default logger for
+// the duration of the test, so entries logged by packages such as the logger
+// middleware and the ginhttp client are captured as well. Both are restored
+// with t.Cleanup.
+//
+// Captured Entries:
+// Entries keep the level, message and field values passed to the logger,
+// after sensitive fields have been redacted. Fields added by the logger, such
+// as "trace_id" from the *Ctx functions, are included:
+//
+//	for _, entry := range rec.Level("WARN") {
+//	    t.Log(entry.Message, entry.Fields["http.response.status_code"])
+//	}
+//
+// Code that receives a *log.Logger rather than using the default logger can
+// be given the recording logger directly:
+//
+//	worker := NewWorker(rec.Logger().Named("worker"))
+package logtest
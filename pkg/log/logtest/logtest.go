@@ -0,0 +1,236 @@
+package logtest
+
+import (
+	"fmt"
+	"io"
+	stdlog "log"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+)
+
+// Entry is a captured log entry. Fields hold the values passed to the
+// logger, after redaction, rather than their JSON encoding.
+type Entry struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]any
+}
+
+// Recorder captures the entries written through the default pkg/log logger,
+// the loggers derived from it and log/slog's default logger
+type Recorder struct {
+	t      testing.TB
+	logger *log.Logger
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New installs a recording logger as the default pkg/log logger and as the
+// backend of log/slog's default logger, capturing entries at every level.
+// The previous loggers are restored when the test finishes.
+//
+// Tests using New change process-wide loggers, so they must not run in
+// parallel with other tests that log.
+func New(t testing.TB) *Recorder {
+	t.Helper()
+
+	r := &Recorder{t: t}
+	r.logger = log.New(log.Options{
+		Level: "trace",
+		Sinks: []log.Sink{{Output: io.Discard, Encoder: r}},
+	})
+
+	prevLogger := log.Default()
+	prevSlog := slog.Default()
+	prevStdWriter, prevStdFlags := stdlog.Writer(), stdlog.Flags()
+
+	log.SetDefault(r.logger)
+	slog.SetDefault(slog.New(r.logger.Handler()))
+
+	t.Cleanup(func() {
+		log.SetDefault(prevLogger)
+		slog.SetDefault(prevSlog)
+
+		// slog.SetDefault redirects the standard library logger, which
+		// restoring slog's own default handler does not undo
+		stdlog.SetOutput(prevStdWriter)
+		stdlog.SetFlags(prevStdFlags)
+	})
+
+	return r
+}
+
+// Logger returns the recording logger, for code that takes a *log.Logger
+// instead of using the default logger
+func (r *Recorder) Logger() *log.Logger {
+	return r.logger
+}
+
+// Encode implements log.Encoder by recording the entry. Nothing is written
+// to the sink's output.
+func (r *Recorder) Encode(entry log.Entry) ([]byte, error) {
+	fields := make(map[string]any, len(entry.Fields))
+	for k, v := range entry.Fields {
+		fields[k] = v
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Entry{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  fields,
+	})
+
+	return nil, nil
+}
+
+// Entries returns the captured entries in the order they were logged
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Level returns the captured entries at the given level, such as "ERROR".
+// The level is matched case-insensitively.
+func (r *Recorder) Level(level string) []Entry {
+	var entries []Entry
+	for _, entry := range r.Entries() {
+		if strings.EqualFold(entry.Level, level) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Count returns the number of captured entries at the given level, or of
+// all entries if level is empty
+func (r *Recorder) Count(level string) int {
+	if level == "" {
+		return len(r.Entries())
+	}
+	return len(r.Level(level))
+}
+
+// Reset discards the captured entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Find returns the first entry with the given level and message whose
+// fields include the given fields. An empty level matches any level.
+func (r *Recorder) Find(level, msg string, fields map[string]any) (Entry, bool) {
+	for _, entry := range r.Entries() {
+		if entry.matches(level, msg, fields) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// AssertContains reports a test error unless an entry with the given level
+// and message, whose fields include the given fields, was captured. Field
+// values are compared with reflect.DeepEqual.
+//
+//	rec.AssertContains("ERROR", "Query failed", map[string]any{"table": "users"})
+func (r *Recorder) AssertContains(level, msg string, fields map[string]any) bool {
+	r.t.Helper()
+
+	if _, ok := r.Find(level, msg, fields); ok {
+		return true
+	}
+
+	r.t.Errorf("No %s entry %q with fields %v was logged. Captured entries:\n%s",
+		levelOrAny(level), msg, fields, r.describe())
+	return false
+}
+
+// AssertNotContains reports a test error if an entry with the given level
+// and message, whose fields include the given fields, was captured
+func (r *Recorder) AssertNotContains(level, msg string, fields map[string]any) bool {
+	r.t.Helper()
+
+	entry, ok := r.Find(level, msg, fields)
+	if !ok {
+		return true
+	}
+
+	r.t.Errorf("Unexpected %s entry %q was logged with fields %v", entry.Level, entry.Message, entry.Fields)
+	return false
+}
+
+// AssertCount reports a test error unless exactly want entries were captured
+// at the given level, or in total if level is empty
+func (r *Recorder) AssertCount(level string, want int) bool {
+	r.t.Helper()
+
+	if got := r.Count(level); got != want {
+		r.t.Errorf("Got %d %s entries, want %d. Captured entries:\n%s", got, levelOrAny(level), want, r.describe())
+		return false
+	}
+	return true
+}
+
+// matches reports whether the entry has the given level and message and
+// includes the given fields
+func (e Entry) matches(level, msg string, fields map[string]any) bool {
+	if level != "" && !strings.EqualFold(e.Level, level) {
+		return false
+	}
+	if e.Message != msg {
+		return false
+	}
+
+	for k, want := range fields {
+		got, ok := e.Fields[k]
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// describe lists the captured entries for failure messages
+func (r *Recorder) describe() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return "  (none)"
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "  %-5s %q", entry.Level, entry.Message)
+
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%v", k, entry.Fields[k])
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// levelOrAny names the level in failure messages
+func levelOrAny(level string) string {
+	if level == "" {
+		return "any-level"
+	}
+	return strings.ToUpper(level)
+}
@@ -0,0 +1,160 @@
+package logtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+)
+
+// fakeTB records failures instead of failing the test, so assertion
+// failures can be tested
+type fakeTB struct {
+	testing.TB
+	failures []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeTB) cleanup() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestRecorderCapturesDefaultAndSlogEntries(t *testing.T) {
+	// Given
+	rec := New(t)
+	ctx := log.ContextWithTraceID(context.Background(), "trace-1")
+
+	// When
+	log.Infof("package entry", map[string]any{"count": 3})
+	log.With(map[string]any{"component": "worker"}).WarnCtx(ctx, "child entry", nil)
+	slog.Error("slog entry", "status", 500)
+
+	// Then
+	entries := rec.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Got %d entries, want 3: %+v", len(entries), entries)
+	}
+
+	rec.AssertContains("INFO", "package entry", map[string]any{"count": 3})
+	rec.AssertContains("warn", "child entry", map[string]any{"component": "worker", "trace_id": "trace-1"})
+	rec.AssertContains("ERROR", "slog entry", map[string]any{"status": int64(500)})
+	rec.AssertCount("", 3)
+	rec.AssertCount("DEBUG", 0)
+}
+
+func TestRecorderCapturesRedactedValues(t *testing.T) {
+	// Given
+	rec := New(t)
+
+	// When
+	log.Infof("login", map[string]any{"password": "hunter2"})
+
+	// Then
+	rec.AssertContains("INFO", "login", map[string]any{"password": log.RedactedValue})
+}
+
+func TestRecorderLoggerCanBeInjected(t *testing.T) {
+	// Given
+	rec := New(t)
+	logger := rec.Logger().Named("db")
+
+	// When
+	logger.Debugf("query", map[string]any{"table": "users"})
+
+	// Then
+	entry, ok := rec.Find("DEBUG", "query", nil)
+	if !ok {
+		t.Fatalf("Entry was not captured")
+	}
+	if entry.Fields["logger"] != "db" || entry.Fields["table"] != "users" {
+		t.Errorf("Fields = %v, want logger and table", entry.Fields)
+	}
+}
+
+func TestRecorderLevelAndReset(t *testing.T) {
+	// Given
+	rec := New(t)
+	log.Errorf("first", nil)
+	log.Infof("second", nil)
+	log.Errorf("third", nil)
+
+	// When
+	errorEntries := rec.Level("error")
+	rec.Reset()
+
+	// Then
+	if len(errorEntries) != 2 || errorEntries[0].Message != "first" || errorEntries[1].Message != "third" {
+		t.Errorf("Level(error) = %+v, want first and third", errorEntries)
+	}
+	if rec.Count("") != 0 {
+		t.Errorf("Count after Reset = %d, want 0", rec.Count(""))
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	// Given
+	tb := &fakeTB{}
+	rec := New(tb)
+	defer tb.cleanup()
+	log.Infof("present", map[string]any{"id": 1})
+
+	// When
+	okContains := rec.AssertContains("INFO", "present", map[string]any{"id": 2})
+	okMissing := rec.AssertContains("ERROR", "missing", nil)
+	okNotContains := rec.AssertNotContains("", "present", nil)
+	okCount := rec.AssertCount("INFO", 2)
+
+	// Then
+	if okContains || okMissing || okNotContains || okCount {
+		t.Errorf("Assertions returned true for failing conditions")
+	}
+	if len(tb.failures) != 4 {
+		t.Fatalf("Got %d failures, want 4: %v", len(tb.failures), tb.failures)
+	}
+	if !strings.Contains(tb.failures[0], `INFO  "present" id=1`) {
+		t.Errorf("Failure does not list captured entries:\n%s", tb.failures[0])
+	}
+}
+
+func TestCleanupRestoresPreviousLoggers(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	prev := log.New(log.Options{Output: buf})
+	log.SetDefault(prev)
+	defer log.SetDefault(log.New(log.Options{}))
+
+	prevSlog := slog.Default()
+	tb := &fakeTB{}
+	rec := New(tb)
+
+	// When
+	tb.cleanup()
+	log.Infof("after cleanup", nil)
+
+	// Then
+	if rec.Count("") != 0 {
+		t.Errorf("Recorder captured %d entries after cleanup", rec.Count(""))
+	}
+	if !strings.Contains(buf.String(), "after cleanup") {
+		t.Errorf("Previous logger did not receive the entry, got %q", buf.String())
+	}
+	if slog.Default() != prevSlog {
+		t.Errorf("slog default logger was not restored")
+	}
+}
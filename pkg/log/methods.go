@@ -1,80 +1,194 @@
 package log
 
 import (
-	"fmt"
+	"context"
 	"io"
-	"os"
 )
 
 // Init initializes the logger with a message
 func Init(msg string) {
-	defaultLogger.initialized = true
-	defaultLogger.log(infolevel, msg, nil)
+	logger := defaultLogger.Load()
+	logger.initialized.Store(true)
+	logger.log(infolevel, msg, nil)
+}
+
+// Default returns the logger used by the package-level functions
+func Default() *Logger {
+	return &Logger{core: defaultLogger.Load()}
+}
+
+// SetDefault replaces the logger used by the package-level functions. A nil
+// or zero Logger leaves the default logger unchanged.
+func SetDefault(l *Logger) {
+	if l == nil || l.core == nil {
+		return
+	}
+	defaultLogger.Store(l.core)
+}
+
+// With returns a child of the default logger that includes the given fields
+func With(fields map[string]any) *Logger {
+	return Default().With(fields)
 }
 
 // Tracef logs a message at trace level with fields
 func Tracef(msg string, fields map[string]any) {
-	defaultLogger.log(tracelevel, msg, fields)
+	defaultLogger.Load().log(tracelevel, msg, fields)
 }
 
 // Debugf logs a message at debug level with fields
 func Debugf(msg string, fields map[string]any) {
-	defaultLogger.log(debuglevel, msg, fields)
+	defaultLogger.Load().log(debuglevel, msg, fields)
 }
 
 // Infof logs a message at info level with fields
 func Infof(msg string, fields map[string]any) {
-	defaultLogger.log(infolevel, msg, fields)
+	defaultLogger.Load().log(infolevel, msg, fields)
 }
 
 // Warnf logs a message at warn level with fields
 func Warnf(msg string, fields map[string]any) {
-	defaultLogger.log(warnlevel, msg, fields)
+	defaultLogger.Load().log(warnlevel, msg, fields)
 }
 
 // Errorf logs a message at error level with fields
 func Errorf(msg string, fields map[string]any) {
-	defaultLogger.log(errorlevel, msg, fields)
+	defaultLogger.Load().log(errorlevel, msg, fields)
+}
+
+// Panicf logs a message at panic level with fields and then panics with a
+// *PanicError
+func Panicf(msg string, fields map[string]any) {
+	defaultLogger.Load().log(paniclevel, msg, fields)
+	panic(&PanicError{Message: msg, Fields: fields})
 }
 
 // Fatalf logs a message at fatal level with fields and then exits
 func Fatalf(msg string, fields map[string]any) {
-	defaultLogger.log(fatallevel, msg, fields)
+	defaultLogger.Load().log(fatallevel, msg, fields)
+}
+
+// TraceCtx logs a message at trace level with fields and the trace, span and user IDs from ctx
+func TraceCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, tracelevel, msg, fields)
+}
+
+// DebugCtx logs a message at debug level with fields and the trace, span and user IDs from ctx
+func DebugCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, debuglevel, msg, fields)
+}
+
+// InfoCtx logs a message at info level with fields and the trace, span and user IDs from ctx
+func InfoCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, infolevel, msg, fields)
+}
+
+// WarnCtx logs a message at warn level with fields and the trace, span and user IDs from ctx
+func WarnCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, warnlevel, msg, fields)
+}
+
+// ErrorCtx logs a message at error level with fields and the trace, span and user IDs from ctx
+func ErrorCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, errorlevel, msg, fields)
+}
+
+// PanicCtx logs a message at panic level with fields and the trace, span and user IDs from ctx and then panics with a *PanicError
+func PanicCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, paniclevel, msg, fields)
+	panic(&PanicError{Message: msg, Fields: fields})
+}
+
+// FatalCtx logs a message at fatal level with fields and the trace, span and user IDs from ctx and then exits
+func FatalCtx(ctx context.Context, msg string, fields map[string]any) {
+	defaultLogger.Load().logCtx(ctx, fatallevel, msg, fields)
 }
 
 // SetFields sets global fields that will be included in all log entries
 func SetFields(fields map[string]any) {
-	for k, v := range fields {
-		defaultLogger.fields[k] = v
-	}
+	defaultLogger.Load().setFields(fields)
 }
 
 // SetOutput sets the output destination for the logger
 func SetOutput(out io.Writer) {
-	defaultLogger.out = out
+	defaultLogger.Load().out.SetOutput(out)
 }
+
+// SetLevel sets the minimum level of the default logger, replacing any
+// temporary level set with SetLevelFor
 func SetLevel(level string) {
-	var lvl logLevel
-
-	// Case-insensitive matching of log level strings
-	switch level {
-	case "TRACE", "trace":
-		lvl = tracelevel
-	case "DEBUG", "debug":
-		lvl = debuglevel
-	case "INFO", "info":
-		lvl = infolevel
-	case "WARN", "warn", "WARNING", "warning":
-		lvl = warnlevel
-	case "ERROR", "error":
-		lvl = errorlevel
-	case "FATAL", "fatal":
-		lvl = fatallevel
-	default:
-		// Default to INFO if invalid level
-		lvl = infolevel
-		fmt.Fprintf(os.Stderr, "Warning: Unknown log level '%s', defaulting to INFO\n", level)
-	}
+	defaultLogger.Load().level.Store(parseLevel(level))
+}
+
+// Tracef logs a message at trace level with fields
+func (l *Logger) Tracef(msg string, fields map[string]any) {
+	l.logger().log(tracelevel, msg, fields)
+}
+
+// Debugf logs a message at debug level with fields
+func (l *Logger) Debugf(msg string, fields map[string]any) {
+	l.logger().log(debuglevel, msg, fields)
+}
+
+// Infof logs a message at info level with fields
+func (l *Logger) Infof(msg string, fields map[string]any) {
+	l.logger().log(infolevel, msg, fields)
+}
+
+// Warnf logs a message at warn level with fields
+func (l *Logger) Warnf(msg string, fields map[string]any) {
+	l.logger().log(warnlevel, msg, fields)
+}
+
+// Errorf logs a message at error level with fields
+func (l *Logger) Errorf(msg string, fields map[string]any) {
+	l.logger().log(errorlevel, msg, fields)
+}
+
+// Panicf logs a message at panic level with fields and then panics with a
+// *PanicError
+func (l *Logger) Panicf(msg string, fields map[string]any) {
+	l.logger().log(paniclevel, msg, fields)
+	panic(&PanicError{Message: msg, Fields: fields})
+}
+
+// Fatalf logs a message at fatal level with fields and then exits
+func (l *Logger) Fatalf(msg string, fields map[string]any) {
+	l.logger().log(fatallevel, msg, fields)
+}
+
+// TraceCtx logs a message at trace level with fields and the trace, span and user IDs from ctx
+func (l *Logger) TraceCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, tracelevel, msg, fields)
+}
+
+// DebugCtx logs a message at debug level with fields and the trace, span and user IDs from ctx
+func (l *Logger) DebugCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, debuglevel, msg, fields)
+}
+
+// InfoCtx logs a message at info level with fields and the trace, span and user IDs from ctx
+func (l *Logger) InfoCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, infolevel, msg, fields)
+}
+
+// WarnCtx logs a message at warn level with fields and the trace, span and user IDs from ctx
+func (l *Logger) WarnCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, warnlevel, msg, fields)
+}
+
+// ErrorCtx logs a message at error level with fields and the trace, span and user IDs from ctx
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, errorlevel, msg, fields)
+}
+
+// PanicCtx logs a message at panic level with fields and the trace, span and user IDs from ctx and then panics with a *PanicError
+func (l *Logger) PanicCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, paniclevel, msg, fields)
+	panic(&PanicError{Message: msg, Fields: fields})
+}
 
-	defaultLogger.level = lvl
+// FatalCtx logs a message at fatal level with fields and the trace, span and user IDs from ctx and then exits
+func (l *Logger) FatalCtx(ctx context.Context, msg string, fields map[string]any) {
+	l.logger().logCtx(ctx, fatallevel, msg, fields)
 }
@@ -0,0 +1,490 @@
+package log
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// RedactedValue replaces the values of sensitive fields
+const RedactedValue = "[REDACTED]"
+
+// redactTag is the struct tag that marks a field as sensitive:
+//
+//	type Credentials struct {
+//	    Username string `json:"username"`
+//	    Password string `json:"password" log:"redact"`
+//	}
+const redactTag = "redact"
+
+// DefaultRedactPatterns are the key patterns redacted by DefaultRedactor
+var DefaultRedactPatterns = []string{
+	"password",
+	"passwd",
+	"secret",
+	"secretkey",
+	"privatekey",
+	"token",
+	"authorization",
+	"apikey",
+	"cookie",
+}
+
+// Redactor masks the values of sensitive fields before entries are written.
+// A field is sensitive when its key ends with one of the redactor's patterns,
+// or when it is a struct field tagged with `log:"redact"`. Keys are split
+// into words at "-", "_", "." and camelCase boundaries and compared ignoring
+// case, so "csrf_token" and "accessToken" match the pattern "token" while
+// "token_count" and "max_tokens" do not, and "X-Api-Key" matches "apikey".
+//
+// Nested maps, slices and structs are redacted recursively. Values other than
+// maps of fields are redacted in their JSON encoding, so struct tags and
+// field order are kept, and values without sensitive fields are logged as
+// they are.
+//
+// A Redactor is safe for concurrent use, and a nil Redactor redacts nothing.
+type Redactor struct {
+	patterns map[string]struct{}
+	types    sync.Map // reflect.Type -> bool, whether values of the type need redaction
+}
+
+// NewRedactor creates a redactor for the given key patterns. A redactor
+// without patterns only redacts struct fields tagged with `log:"redact"`.
+func NewRedactor(patterns ...string) *Redactor {
+	normalized := make(map[string]struct{}, len(patterns))
+	for _, pattern := range patterns {
+		if p := normalizeKey(pattern); p != "" {
+			normalized[p] = struct{}{}
+		}
+	}
+
+	return &Redactor{patterns: normalized}
+}
+
+// DefaultRedactor returns a redactor for DefaultRedactPatterns
+func DefaultRedactor() *Redactor {
+	return NewRedactor(DefaultRedactPatterns...)
+}
+
+// SetRedactor replaces the redactor of the default logger and the loggers
+// derived from it. Passing nil disables redaction.
+func SetRedactor(r *Redactor) {
+	defaultLogger.Load().redactor.Store(r)
+}
+
+// Redactor returns the redactor used by the logger, or nil if redaction is
+// disabled
+func (l *Logger) Redactor() *Redactor {
+	return l.logger().redactor.Load()
+}
+
+// MatchKey reports whether values stored under key are sensitive
+func (r *Redactor) MatchKey(key string) bool {
+	if r == nil || len(r.patterns) == 0 {
+		return false
+	}
+
+	words := keyWords(key)
+	suffix := ""
+	for i := len(words) - 1; i >= 0; i-- {
+		suffix = words[i] + suffix
+		if _, ok := r.patterns[suffix]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fields returns a copy of fields with sensitive values masked
+func (r *Redactor) Fields(fields map[string]any) map[string]any {
+	if fields == nil || r == nil {
+		return fields
+	}
+
+	redacted := make(map[string]any, len(fields))
+	for k, v := range fields {
+		redacted[k] = v
+	}
+
+	r.redactFields(redacted)
+	return redacted
+}
+
+// Header returns a copy of the header with the values of sensitive headers,
+// such as Authorization and Cookie, masked
+func (r *Redactor) Header(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+
+	redacted := make(http.Header, len(header))
+	for k, values := range header {
+		if r.MatchKey(k) {
+			redacted[k] = []string{RedactedValue}
+			continue
+		}
+		redacted[k] = values
+	}
+
+	return redacted
+}
+
+// Query returns the raw query string with the values of sensitive
+// parameters masked. Parameter order and encoding are otherwise preserved.
+func (r *Redactor) Query(rawQuery string) string {
+	if rawQuery == "" || r == nil {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, hasValue := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if hasValue && r.MatchKey(key) {
+			params[i] = param[:strings.Index(param, "=")+1] + RedactedValue
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// redactFields masks sensitive values of fields in place
+func (r *Redactor) redactFields(fields map[string]any) {
+	for k, v := range fields {
+		if r.MatchKey(k) {
+			fields[k] = RedactedValue
+		} else if redacted, ok := r.redactValue(v); ok {
+			fields[k] = redacted
+		}
+	}
+}
+
+// redactValue returns a redacted copy of v and true if v contains sensitive
+// values, or false if v can be logged as it is. The caller's value is never
+// modified.
+func (r *Redactor) redactValue(v any) (any, bool) {
+	if r == nil {
+		return v, false
+	}
+
+	switch value := v.(type) {
+	case nil, string, bool, int, int64, float64:
+		return v, false
+	case map[string]any:
+		return r.redactMap(value)
+	}
+
+	rv := reflect.ValueOf(v)
+	if !r.needsRedaction(rv.Type()) {
+		return v, false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v, false // The error is reported when the entry is marshaled
+	}
+
+	tagged := map[string]struct{}{}
+	r.taggedKeys(rv, tagged)
+
+	redacted, ok := r.redactJSON(data, tagged)
+	if !ok {
+		return v, false
+	}
+
+	return json.RawMessage(redacted), true
+}
+
+// redactMap returns a redacted copy of fields and true, or false if fields
+// holds no sensitive values
+func (r *Redactor) redactMap(fields map[string]any) (map[string]any, bool) {
+	var redacted map[string]any
+	for k, v := range fields {
+		value := any(RedactedValue)
+		if !r.MatchKey(k) {
+			var ok bool
+			if value, ok = r.redactValue(v); !ok {
+				continue
+			}
+		}
+
+		// Copy the map on the first sensitive value
+		if redacted == nil {
+			redacted = make(map[string]any, len(fields))
+			for k, v := range fields {
+				redacted[k] = v
+			}
+		}
+		redacted[k] = value
+	}
+
+	return redacted, redacted != nil
+}
+
+// taggedKeys adds the JSON names of the fields tagged with `log:"redact"`
+// found in rv to keys
+func (r *Redactor) taggedKeys(rv reflect.Value, keys map[string]struct{}) {
+	if !rv.IsValid() || !r.needsRedaction(rv.Type()) {
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !rv.IsNil() {
+			r.taggedKeys(rv.Elem(), keys)
+		}
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := range rt.NumField() {
+			field := rt.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+
+			if field.Tag.Get("log") == redactTag {
+				keys[name] = struct{}{}
+				continue
+			}
+
+			r.taggedKeys(rv.Field(i), keys)
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			r.taggedKeys(iter.Value(), keys)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			r.taggedKeys(rv.Index(i), keys)
+		}
+	}
+}
+
+// redactJSON masks the values of sensitive keys, and of the keys in tagged,
+// at any depth of data, which must be compact JSON as produced by
+// json.Marshal. It reports whether any value was masked.
+func (r *Redactor) redactJSON(data []byte, tagged map[string]struct{}) ([]byte, bool) {
+	redacted := make([]byte, 0, len(data))
+	masked := false
+
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			redacted = append(redacted, data[i])
+			i++
+			continue
+		}
+
+		end := jsonStringEnd(data, i)
+		redacted = append(redacted, data[i:end]...)
+
+		// In compact JSON a string followed by a colon is an object key
+		if end < len(data) && data[end] == ':' && r.matchJSONKey(data[i:end], tagged) {
+			redacted = append(redacted, ':', '"')
+			redacted = append(redacted, RedactedValue...)
+			redacted = append(redacted, '"')
+			end = jsonValueEnd(data, end+1)
+			masked = true
+		}
+
+		i = end
+	}
+
+	return redacted, masked
+}
+
+// matchJSONKey reports whether the quoted JSON key is sensitive or tagged
+func (r *Redactor) matchJSONKey(quoted []byte, tagged map[string]struct{}) bool {
+	var key string
+	if err := json.Unmarshal(quoted, &key); err != nil {
+		return false
+	}
+
+	if _, ok := tagged[key]; ok {
+		return true
+	}
+
+	return r.MatchKey(key)
+}
+
+// jsonStringEnd returns the index after the JSON string starting at start
+func jsonStringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return len(data)
+}
+
+// jsonValueEnd returns the index after the JSON value starting at start
+func jsonValueEnd(data []byte, start int) int {
+	depth := 0
+	for i := start; i < len(data); {
+		switch data[i] {
+		case '"':
+			i = jsonStringEnd(data, i)
+			if depth == 0 {
+				return i
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+
+	return len(data)
+}
+
+// needsRedaction reports whether values of type t may contain tagged or
+// sensitive fields and must be inspected before marshaling
+func (r *Redactor) needsRedaction(t reflect.Type) bool {
+	if cached, ok := r.types.Load(t); ok {
+		return cached.(bool)
+	}
+
+	result := r.inspectType(t, map[reflect.Type]bool{})
+	r.types.Store(t, result)
+	return result
+}
+
+// inspectType looks for tagged or sensitive fields in t, using visiting to
+// terminate on recursive types
+func (r *Redactor) inspectType(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := r.types.Load(t); ok {
+		return cached.(bool)
+	}
+
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	// Values that control their own encoding, and errors which are
+	// serialized by the encoders, are left untouched
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || t.Implements(errorType) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return r.inspectType(t.Elem(), visiting)
+	case reflect.Interface:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+
+			if field.Tag.Get("log") == redactTag || r.MatchKey(name) || r.inspectType(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	errorType         = reflect.TypeFor[error]()
+)
+
+// jsonFieldName returns the name encoding/json uses for a struct field, and
+// whether the field is skipped entirely
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, false
+}
+
+// normalizeKey lowercases key and strips separators so that "X-Api-Key",
+// "api_key" and "apiKey" all give the pattern "apikey"
+func normalizeKey(key string) string {
+	return strings.Join(keyWords(key), "")
+}
+
+// keyWords splits key into lowercase words at separators and camelCase
+// boundaries, so that "X-Api-Key", "api_key" and "APIKey" all give "api" and
+// "key"
+func keyWords(key string) []string {
+	runes := []rune(key)
+	words := make([]string, 0, 4)
+	start := 0
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || r == '.' || r == ' ':
+			if i > start {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(r) && isWordEnd(runes, i):
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// isWordEnd reports whether the upper case rune at i starts a new camelCase
+// word: after a lower case letter or digit as in "apiKey", or at the end of
+// an acronym as in "APIKey"
+func isWordEnd(runes []rune, i int) bool {
+	prev := runes[i-1]
+	if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
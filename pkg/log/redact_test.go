@@ -0,0 +1,225 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type testCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	PIN      string `json:"pin" log:"redact"`
+	Internal string `json:"-"`
+}
+
+type testAccount struct {
+	ID          int             `json:"id"`
+	Credentials testCredentials `json:"credentials"`
+}
+
+type testClient struct {
+	ID     int    `json:"id,string"`
+	Name   string `json:"name,omitempty"`
+	Secret string `json:"secret"`
+	Scope  string `json:"scope"`
+}
+
+type testUsage struct {
+	Model      string `json:"model"`
+	TokenCount int    `json:"token_count"`
+}
+
+func TestRedactorMatchKey(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected bool
+	}{
+		{"password", true},
+		{"userPassword", true},
+		{"Authorization", true},
+		{"X-Api-Key", true},
+		{"api_key", true},
+		{"csrf_token", true},
+		{"SessionSecret", true},
+		{"Set-Cookie", true},
+		{"accessToken", true},
+		{"APIKey", true},
+		{"X-CSRF-Token", true},
+		{"secret_key", true},
+		{"username", false},
+		{"trace_id", false},
+		{"http.request.method", false},
+		{"session_id", false},
+		{"max_tokens", false},
+		{"tokens_used", false},
+		{"token_count", false},
+		{"cookie_consent", false},
+		{"secretary", false},
+	}
+
+	r := DefaultRedactor()
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			// When
+			matched := r.MatchKey(tc.key)
+
+			// Then
+			if matched != tc.expected {
+				t.Errorf("MatchKey(%q) = %v, want %v", tc.key, matched, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLoggerRedactsSensitiveFields(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf}).With(map[string]any{"session_secret": "abc"})
+	account := testAccount{
+		ID:          7,
+		Credentials: testCredentials{Username: "alice", Password: "hunter2", PIN: "1234", Internal: "x"},
+	}
+
+	// When
+	logger.Infof("login attempt", map[string]any{
+		"password":       "hunter2",
+		"account":        account,
+		"nested":         map[string]any{"token": "t-123", "scope": "read"},
+		"headers":        map[string]string{"Authorization": "Bearer t-123", "Accept": "application/json"},
+		"session_id":     "s-42",
+		"token_count":    512,
+		"cookie_consent": true,
+	})
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("hunter2")) || bytes.Contains(buf.Bytes(), []byte("t-123")) || bytes.Contains(buf.Bytes(), []byte("1234")) {
+		t.Fatalf("Sensitive values leaked into output: %s", buf.String())
+	}
+
+	fields := *entry.Fields
+	if fields["password"] != RedactedValue || fields["session_secret"] != RedactedValue {
+		t.Errorf("Expected top-level fields to be redacted: %+v", fields)
+	}
+	if fields["session_id"] != "s-42" || fields["token_count"] != float64(512) || fields["cookie_consent"] != true {
+		t.Errorf("Fields merely starting with a sensitive word should be kept: %+v", fields)
+	}
+
+	credentials := fields["account"].(map[string]any)["credentials"].(map[string]any)
+	if credentials["username"] != "alice" || credentials["password"] != RedactedValue || credentials["pin"] != RedactedValue {
+		t.Errorf("Unexpected credentials: %+v", credentials)
+	}
+	if _, exists := credentials["Internal"]; exists {
+		t.Errorf("Fields tagged json:\"-\" should be omitted: %+v", credentials)
+	}
+
+	if fields["nested"].(map[string]any)["scope"] != "read" {
+		t.Errorf("Non-sensitive nested fields should be kept: %+v", fields["nested"])
+	}
+
+	if fields["headers"].(map[string]any)["Accept"] != "application/json" {
+		t.Errorf("Non-sensitive headers should be kept: %+v", fields["headers"])
+	}
+}
+
+func TestRedactorDoesNotModifyCallerValues(t *testing.T) {
+	// Given
+	nested := map[string]any{"token": "t-123"}
+	fields := map[string]any{"nested": nested}
+
+	// When
+	redacted := DefaultRedactor().Fields(fields)
+
+	// Then
+	if nested["token"] != "t-123" {
+		t.Errorf("Caller's nested map was modified: %+v", nested)
+	}
+	if redacted["nested"].(map[string]any)["token"] != RedactedValue {
+		t.Errorf("Expected nested token to be redacted: %+v", redacted)
+	}
+}
+
+func TestRedactorKeepsJSONEncodingOfStructs(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+
+	// When
+	logger.Infof("client registered", map[string]any{
+		"client": &testClient{ID: 7, Secret: "s-123", Scope: "read"},
+	})
+
+	// Then
+	expected := `"client":{"id":"7","secret":"` + RedactedValue + `","scope":"read"}`
+	if !bytes.Contains(buf.Bytes(), []byte(expected)) {
+		t.Errorf("Expected output to contain %s, got: %s", expected, buf.String())
+	}
+}
+
+func TestRedactorKeepsValuesWithoutSensitiveFields(t *testing.T) {
+	// Given
+	usage := testUsage{Model: "small", TokenCount: 512}
+
+	// When
+	redacted := DefaultRedactor().Fields(map[string]any{"usage": usage})
+
+	// Then
+	if redacted["usage"] != usage {
+		t.Errorf("Expected the struct to be kept as is, got %#v", redacted["usage"])
+	}
+}
+
+func TestRedactorHeaderAndQuery(t *testing.T) {
+	// Given
+	r := DefaultRedactor()
+	header := http.Header{
+		"Authorization": {"Bearer t-123"},
+		"Content-Type":  {"application/json"},
+	}
+
+	// When
+	redactedHeader := r.Header(header)
+	redactedQuery := r.Query("page=2&access_token=t-123&api%5Fkey=k&q=a%20b")
+
+	// Then
+	if redactedHeader.Get("Authorization") != RedactedValue || redactedHeader.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected header: %+v", redactedHeader)
+	}
+	if header.Get("Authorization") != "Bearer t-123" {
+		t.Error("Caller's header was modified")
+	}
+
+	expectedQuery := "page=2&access_token=" + RedactedValue + "&api%5Fkey=" + RedactedValue + "&q=a%20b"
+	if redactedQuery != expectedQuery {
+		t.Errorf("Query = %q, want %q", redactedQuery, expectedQuery)
+	}
+}
+
+func TestRedactorWithoutPatternsOnlyRedactsTags(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, Redactor: NewRedactor()})
+
+	// When
+	logger.Infof("tagged only", map[string]any{
+		"password":    "visible",
+		"credentials": testCredentials{PIN: "1234"},
+	})
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+
+	fields := *entry.Fields
+	if fields["password"] != "visible" || fields["credentials"].(map[string]any)["pin"] != RedactedValue {
+		t.Errorf("Unexpected fields: %+v", fields)
+	}
+}
@@ -0,0 +1,287 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp added to the names of rotated files
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFileOptions configures a RotatingFile
+type RotatingFileOptions struct {
+	// Filename is the file entries are written to. The file is created with
+	// mode 0600, and its directory with mode 0750 if it does not exist, as
+	// entries may hold sensitive data.
+	Filename string
+
+	// MaxSize rotates the file before a write would grow it beyond MaxSize
+	// bytes. Zero disables size-based rotation.
+	MaxSize int64
+
+	// Interval rotates the file once it has been written to for Interval.
+	// Zero disables time-based rotation.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files kept. Zero keeps all of
+	// them.
+	MaxBackups int
+
+	// MaxAge removes rotated files older than MaxAge. Zero keeps them
+	// regardless of age.
+	MaxAge time.Duration
+}
+
+// RotatingFile is an io.Writer that writes to a file and rotates it by size
+// and age. Rotated files are renamed with a timestamp, so "app.log" becomes
+// "app-20240422T150405.000.log", and are removed according to MaxBackups and
+// MaxAge. It is typically used as the Output of a Sink:
+//
+//	file, err := log.NewRotatingFile(log.RotatingFileOptions{
+//	    Filename:   "/var/log/app/error.log",
+//	    MaxSize:    100 << 20,
+//	    MaxBackups: 7,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	defer file.Close()
+//
+//	log.SetSinks(log.Sink{Output: file, Level: "error"})
+//
+// A RotatingFile is safe for concurrent use.
+type RotatingFile struct {
+	opts RotatingFileOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFile opens the file for appending, creating it if needed
+func NewRotatingFile(opts RotatingFileOptions) (*RotatingFile, error) {
+	if opts.Filename == "" {
+		return nil, errors.New("rotating file: filename is required")
+	}
+
+	f := &RotatingFile{opts: opts, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Write writes p to the current file, rotating it first if p would exceed
+// MaxSize or the file is older than Interval
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with a timestamp and opens a
+// new file in its place
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	return f.rotate()
+}
+
+// Close closes the current file. Writes after Close fail with os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// shouldRotate reports whether the file must be rotated before writing n
+// bytes. An empty file is never rotated, so entries larger than MaxSize are
+// still written.
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.opts.MaxSize > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+
+	return f.opts.Interval > 0 && f.now().Sub(f.openedAt) >= f.opts.Interval
+}
+
+// open opens the file for appending, creating its directory if needed
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.opts.Filename), 0o750); err != nil {
+		return fmt.Errorf("rotating file: %w", err)
+	}
+
+	file, err := os.OpenFile(f.opts.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("rotating file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(fmt.Errorf("rotating file: %w", err), file.Close())
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// rotate renames the current file to its backup name, opens a new file and
+// removes expired backups
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("rotating file: %w", err)
+	}
+	f.file = nil
+
+	if err := os.Rename(f.opts.Filename, f.backupName(f.now())); err != nil {
+		// Keep appending to the current file rather than losing entries
+		if openErr := f.open(); openErr != nil {
+			return errors.Join(fmt.Errorf("rotating file: %w", err), openErr)
+		}
+		return fmt.Errorf("rotating file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.removeExpired()
+	return nil
+}
+
+// backupName returns the name a file rotated at t is renamed to
+func (f *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := f.backupPattern()
+	name := prefix + t.Format(backupTimeFormat) + ext
+
+	// Keep earlier backups rotated within the same millisecond
+	for i := 1; fileExists(name); i++ {
+		name = fmt.Sprintf("%s%s.%d%s", prefix, t.Format(backupTimeFormat), i, ext)
+	}
+
+	return name
+}
+
+// backupPattern returns the path prefix and extension of backup names
+func (f *RotatingFile) backupPattern() (string, string) {
+	ext := filepath.Ext(f.opts.Filename)
+	return strings.TrimSuffix(f.opts.Filename, ext) + "-", ext
+}
+
+// removeExpired removes the backups exceeding MaxBackups or older than
+// MaxAge. Failures are reported on stderr, as they must not stop logging.
+func (f *RotatingFile) removeExpired() {
+	if f.opts.MaxBackups <= 0 && f.opts.MaxAge <= 0 {
+		return
+	}
+
+	prefix, ext := f.backupPattern()
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing rotated log files: %v\n", err)
+		return
+	}
+
+	type backup struct {
+		name      string
+		rotatedAt time.Time
+		seq       int
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, base), ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+
+		rotatedAt, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+
+		// Backups rotated within the same millisecond carry a ".N" suffix
+		seq := 0
+		if suffix := stamp[len(backupTimeFormat):]; suffix != "" {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(suffix, ".")); err != nil || seq <= 0 {
+				continue
+			}
+		}
+
+		backups = append(backups, backup{name: filepath.Join(dir, name), rotatedAt: rotatedAt, seq: seq})
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].rotatedAt.Equal(backups[j].rotatedAt) {
+			return backups[i].rotatedAt.After(backups[j].rotatedAt)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+
+	now := f.now()
+	for i, b := range backups {
+		expired := f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups
+		expired = expired || f.opts.MaxAge > 0 && now.Sub(b.rotatedAt) > f.opts.MaxAge
+		if !expired {
+			continue
+		}
+
+		if err := os.Remove(b.name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Error removing rotated log file: %v\n", err)
+		}
+	}
+}
+
+// fileExists reports whether a file exists at name
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
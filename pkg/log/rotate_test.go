@@ -0,0 +1,204 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a controllable time for rotation tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestRotatingFile(t *testing.T, opts RotatingFileOptions, clock *fakeClock) *RotatingFile {
+	t.Helper()
+
+	f, err := NewRotatingFile(opts)
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	f.now = clock.Now
+	f.openedAt = clock.Now()
+	return f
+}
+
+// backupFiles returns the names of the rotated files next to filename
+func backupFiles(t *testing.T, filename string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(strings.TrimSuffix(filename, ".log") + "-*.log")
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, filepath.Base(match))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "logs", "app.log")
+	clock := &fakeClock{now: time.Date(2024, 4, 22, 15, 4, 5, 0, time.Local)}
+	f := newTestRotatingFile(t, RotatingFileOptions{Filename: filename, MaxSize: 10}, clock)
+
+	// When
+	f.Write([]byte("first\n"))
+	f.Write([]byte("second\n"))
+
+	// Then
+	backups := backupFiles(t, filename)
+	if len(backups) != 1 || backups[0] != "app-20240422T150405.000.log" {
+		t.Fatalf("Backups = %v, want [app-20240422T150405.000.log]", backups)
+	}
+
+	backup, _ := os.ReadFile(filepath.Join(filepath.Dir(filename), backups[0]))
+	current, _ := os.ReadFile(filename)
+	if string(backup) != "first\n" || string(current) != "second\n" {
+		t.Errorf("Backup = %q and current = %q, want %q and %q", backup, current, "first\n", "second\n")
+	}
+}
+
+func TestRotatingFileRotatesByInterval(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Date(2024, 4, 22, 15, 0, 0, 0, time.Local)}
+	f := newTestRotatingFile(t, RotatingFileOptions{Filename: filename, Interval: time.Hour}, clock)
+
+	// When
+	f.Write([]byte("first hour\n"))
+	clock.now = clock.now.Add(30 * time.Minute)
+	f.Write([]byte("still first hour\n"))
+	clock.now = clock.now.Add(30 * time.Minute)
+	f.Write([]byte("second hour\n"))
+
+	// Then
+	if backups := backupFiles(t, filename); len(backups) != 1 {
+		t.Errorf("Got %d backups, want 1", len(backups))
+	}
+
+	current, _ := os.ReadFile(filename)
+	if string(current) != "second hour\n" {
+		t.Errorf("Current file = %q, want %q", current, "second hour\n")
+	}
+}
+
+func TestRotatingFileRemovesExpiredBackups(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     RotatingFileOptions
+		expected []string
+	}{
+		{
+			name: "max backups",
+			opts: RotatingFileOptions{MaxBackups: 2},
+			expected: []string{
+				"app-20240422T170000.000.log",
+				"app-20240422T180000.000.log",
+			},
+		},
+		{
+			name: "max age",
+			opts: RotatingFileOptions{MaxAge: 90 * time.Minute},
+			expected: []string{
+				"app-20240422T170000.000.log",
+				"app-20240422T180000.000.log",
+			},
+		},
+		{
+			name: "no limits",
+			opts: RotatingFileOptions{},
+			expected: []string{
+				"app-20240422T150000.000.log",
+				"app-20240422T160000.000.log",
+				"app-20240422T170000.000.log",
+				"app-20240422T180000.000.log",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			filename := filepath.Join(t.TempDir(), "app.log")
+			clock := &fakeClock{now: time.Date(2024, 4, 22, 15, 0, 0, 0, time.Local)}
+			tc.opts.Filename = filename
+			f := newTestRotatingFile(t, tc.opts, clock)
+
+			// When - rotate once per hour
+			for range 4 {
+				f.Write([]byte("entry\n"))
+				if err := f.Rotate(); err != nil {
+					t.Fatalf("Rotate returned error: %v", err)
+				}
+				clock.now = clock.now.Add(time.Hour)
+			}
+
+			// Then
+			backups := backupFiles(t, filename)
+			if strings.Join(backups, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Backups = %v, want %v", backups, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRotatingFileKeepsBackupsRotatedInTheSameMillisecond(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Date(2024, 4, 22, 15, 0, 0, 0, time.Local)}
+	f := newTestRotatingFile(t, RotatingFileOptions{Filename: filename, MaxBackups: 2}, clock)
+
+	// When
+	for range 3 {
+		f.Write([]byte("entry\n"))
+		f.Rotate()
+	}
+
+	// Then - the oldest of the three backups is removed
+	backups := backupFiles(t, filename)
+	expected := []string{"app-20240422T150000.000.1.log", "app-20240422T150000.000.2.log"}
+	if strings.Join(backups, ",") != strings.Join(expected, ",") {
+		t.Errorf("Backups = %v, want %v", backups, expected)
+	}
+}
+
+func TestRotatingFileAsSinkOutput(t *testing.T) {
+	// Given
+	filename := filepath.Join(t.TempDir(), "error.log")
+	f, err := NewRotatingFile(RotatingFileOptions{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer f.Close()
+
+	logger := New(Options{Sinks: []Sink{{Output: f, Level: "error"}}})
+
+	// When
+	logger.Infof("not written", nil)
+	logger.Errorf("written", nil)
+
+	// Then
+	data, _ := os.ReadFile(filename)
+	if !strings.Contains(string(data), `"message":"written"`) || strings.Contains(string(data), "not written") {
+		t.Errorf("File content = %s, want only the ERROR entry", data)
+	}
+
+	f.Close()
+	if _, err := f.Write([]byte("late\n")); err == nil {
+		t.Errorf("Write after Close succeeded, want os.ErrClosed")
+	}
+}
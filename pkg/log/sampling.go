@@ -0,0 +1,199 @@
+package log
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// samplerBuckets is the number of counters used to track messages. Distinct
+// messages that hash to the same bucket share a counter.
+const samplerBuckets = 4096
+
+// SamplingOptions configures sampling of repeated log entries, so noisy code
+// paths cannot saturate the output. Entries are grouped by level and message:
+// within each interval the first Initial entries of a group are written, after
+// which only every Thereafter-th entry is written. FATAL entries are never
+// sampled.
+//
+// The number of dropped entries per group is written periodically as a
+// summary entry at the group's level with the message "log entries dropped by
+// sampling".
+type SamplingOptions struct {
+	// Interval is the window in which entries are counted. Defaults to 1s.
+	Interval time.Duration
+
+	// Initial is the number of entries written per interval before sampling
+	// starts. Defaults to 100.
+	Initial int
+
+	// Thereafter writes one of every Thereafter entries once Initial is
+	// exceeded. Defaults to 100.
+	Thereafter int
+
+	// SummaryInterval is how often dropped entry counts are written.
+	// Defaults to 10s.
+	SummaryInterval time.Duration
+}
+
+// SetSampling enables sampling on the default logger and the loggers derived
+// from it. Passing nil disables sampling.
+func SetSampling(opts *SamplingOptions) {
+	logger := defaultLogger.Load()
+	if opts == nil {
+		logger.sampler.Store(nil)
+		return
+	}
+
+	logger.sampler.Store(newSampler(logger, *opts))
+}
+
+// sampleKey identifies a group of entries for sampling
+type sampleKey struct {
+	level logLevel
+	msg   string
+}
+
+// sampleCounter counts entries of a group within the current interval
+type sampleCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// inc increments the counter, restarting it when the interval has elapsed
+func (c *sampleCounter) inc(now time.Time, interval time.Duration) uint64 {
+	nowNanos := now.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > nowNanos {
+		return c.count.Add(1)
+	}
+
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, nowNanos+interval.Nanoseconds()) {
+		// Another goroutine restarted the interval first
+		return c.count.Add(1)
+	}
+
+	return 1
+}
+
+// sampler decides which entries are written and tracks dropped entries
+type sampler struct {
+	logger          *jsonLogger
+	interval        time.Duration
+	initial         uint64
+	thereafter      uint64
+	summaryInterval time.Duration
+	counters        [samplerBuckets]sampleCounter
+	now             func() time.Time
+	afterFunc       func(time.Duration, func())
+
+	mu               sync.Mutex
+	dropped          map[sampleKey]uint64
+	summaryScheduled bool
+}
+
+// newSampler creates a sampler that writes its summaries through logger
+func newSampler(logger *jsonLogger, opts SamplingOptions) *sampler {
+	s := &sampler{
+		logger:          logger,
+		interval:        opts.Interval,
+		initial:         uint64(max(opts.Initial, 0)),
+		thereafter:      uint64(max(opts.Thereafter, 0)),
+		summaryInterval: opts.SummaryInterval,
+		dropped:         make(map[sampleKey]uint64),
+		now:             time.Now,
+		afterFunc:       func(d time.Duration, f func()) { time.AfterFunc(d, f) },
+	}
+
+	if s.interval <= 0 {
+		s.interval = time.Second
+	}
+
+	if s.initial == 0 {
+		s.initial = 100
+	}
+
+	if s.thereafter == 0 {
+		s.thereafter = 100
+	}
+
+	if s.summaryInterval <= 0 {
+		s.summaryInterval = 10 * time.Second
+	}
+
+	return s
+}
+
+// sample reports whether an entry should be written, recording it as dropped
+// otherwise
+func (s *sampler) sample(level logLevel, msg string) bool {
+	if level == paniclevel || level == fatallevel {
+		return true
+	}
+
+	n := s.counters[bucket(level, msg)].inc(s.now(), s.interval)
+	if n <= s.initial || (n-s.initial)%s.thereafter == 0 {
+		return true
+	}
+
+	s.recordDrop(sampleKey{level: level, msg: msg})
+	return false
+}
+
+// recordDrop counts a dropped entry and schedules a summary if none is pending
+func (s *sampler) recordDrop(key sampleKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dropped[key]++
+	if !s.summaryScheduled {
+		s.summaryScheduled = true
+		s.afterFunc(s.summaryInterval, s.writeSummary)
+	}
+}
+
+// writeSummary writes one entry per group with the number of entries dropped
+// since the previous summary
+func (s *sampler) writeSummary() {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = make(map[sampleKey]uint64)
+	s.summaryScheduled = false
+	s.mu.Unlock()
+
+	keys := make([]sampleKey, 0, len(dropped))
+	for key := range dropped {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].level != keys[j].level {
+			return keys[i].level < keys[j].level
+		}
+		return keys[i].msg < keys[j].msg
+	})
+
+	for _, key := range keys {
+		if !s.logger.shouldLog(key.level) {
+			continue
+		}
+
+		s.logger.write(key.level, "log entries dropped by sampling", map[string]any{
+			"sampled_level":   string(key.level),
+			"sampled_message": key.msg,
+			"dropped":         dropped[key],
+			"interval":        s.summaryInterval.String(),
+		})
+	}
+}
+
+// bucket returns the counter index for a level and message
+func bucket(level logLevel, msg string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(level))
+	_, _ = h.Write([]byte(msg))
+	return h.Sum32() % samplerBuckets
+}
@@ -0,0 +1,184 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that can be read while summaries are
+// written from timer goroutines
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *lockedBuffer) entries(t *testing.T) []testLogEntry {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []testLogEntry
+	scanner := bufio.NewScanner(strings.NewReader(b.buf.String()))
+	for scanner.Scan() {
+		var entry testLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to unmarshal log entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSamplingWritesInitialThenEveryNth(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{
+		Output: buf,
+		Sampling: &SamplingOptions{
+			Interval:        time.Hour,
+			Initial:         3,
+			Thereafter:      5,
+			SummaryInterval: time.Hour,
+		},
+	})
+
+	// When
+	for range 20 {
+		logger.Errorf("validation query failed", nil)
+	}
+	logger.Errorf("different message", nil)
+	logger.Warnf("validation query failed", nil)
+
+	// Then - entries 1-3, 8, 13 and 18 plus the two other groups
+	counts := map[string]int{}
+	for _, entry := range buf.entries(t) {
+		counts[entry.Level+" "+entry.Message]++
+	}
+
+	if counts["ERROR validation query failed"] != 6 {
+		t.Errorf("Got %d sampled entries, want 6", counts["ERROR validation query failed"])
+	}
+	if counts["ERROR different message"] != 1 || counts["WARN validation query failed"] != 1 {
+		t.Errorf("Other groups should not be sampled: %+v", counts)
+	}
+}
+
+func TestSamplingRestartsEachInterval(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{
+		Output: buf,
+		Sampling: &SamplingOptions{
+			Interval:        20 * time.Millisecond,
+			Initial:         1,
+			Thereafter:      1000,
+			SummaryInterval: time.Hour,
+		},
+	})
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	logger.core.sampler.Load().now = func() time.Time { return now }
+
+	// When
+	logger.Warnf("host validation failed", nil)
+	logger.Warnf("host validation failed", nil)
+	now = now.Add(20 * time.Millisecond)
+	logger.Warnf("host validation failed", nil)
+
+	// Then
+	if entries := buf.entries(t); len(entries) != 2 {
+		t.Errorf("Got %d entries, want 2", len(entries))
+	}
+}
+
+func TestSamplingWritesDroppedSummary(t *testing.T) {
+	// Given
+	buf := &lockedBuffer{}
+	logger := New(Options{
+		Output: buf,
+		Sampling: &SamplingOptions{
+			Interval:        time.Hour,
+			Initial:         2,
+			Thereafter:      1000,
+			SummaryInterval: 10 * time.Second,
+		},
+	})
+
+	var scheduled []func()
+	var delay time.Duration
+	logger.core.sampler.Load().afterFunc = func(d time.Duration, f func()) {
+		delay = d
+		scheduled = append(scheduled, f)
+	}
+
+	// When
+	for range 10 {
+		logger.Errorf("validation query failed", nil)
+	}
+
+	if len(scheduled) != 1 || delay != 10*time.Second {
+		t.Fatalf("Expected one summary scheduled after 10s, got %d after %v", len(scheduled), delay)
+	}
+
+	scheduled[0]()
+
+	// Then
+	var summary *testLogEntry
+	for _, entry := range buf.entries(t) {
+		if entry.Message == "log entries dropped by sampling" {
+			summary = &entry
+		}
+	}
+
+	if summary == nil {
+		t.Fatal("Expected a summary entry for dropped entries")
+	}
+
+	fields := *summary.Fields
+	if summary.Level != "ERROR" || fields["sampled_message"] != "validation query failed" || fields["dropped"] != float64(8) {
+		t.Errorf("Unexpected summary entry: %+v %+v", summary, fields)
+	}
+}
+
+func TestSetSamplingAppliesToDerivedLoggers(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &lockedBuffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	child := With(map[string]any{"component": "sentinel"})
+
+	// When
+	SetSampling(&SamplingOptions{Interval: time.Hour, Initial: 1, Thereafter: 1000, SummaryInterval: time.Hour})
+	for range 5 {
+		child.Warnf("Host validation failed", nil)
+	}
+	SetSampling(nil)
+	Warnf("Host validation failed", nil)
+
+	// Then
+	if entries := buf.entries(t); len(entries) != 2 {
+		t.Errorf("Got %d entries, want 2", len(entries))
+	}
+}
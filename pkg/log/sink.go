@@ -0,0 +1,176 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// Sink is a destination for log entries with its own minimum level, format
+// and filter. A logger with sinks writes every entry to each sink that
+// accepts it instead of to its Output:
+//
+//	log.SetSinks(
+//	    log.Sink{Output: os.Stdout, Level: "info"},
+//	    log.Sink{Output: os.Stderr, Level: "error", Format: log.FormatConsole},
+//	)
+//
+// The logger's level still applies first, so it must be at or below the
+// lowest sink level for that sink to receive all of its entries.
+type Sink struct {
+	// Output is the destination for the sink's entries.
+	Output io.Writer
+
+	// Level is the minimum level written to the sink. Defaults to every
+	// entry the logger writes.
+	Level string
+
+	// Format selects the sink's output format. Defaults to the logger's
+	// format.
+	Format string
+
+	// Encoder, when set, formats the sink's entries instead of Format.
+	Encoder Encoder
+
+	// Filter, when set, is called for every entry that passes Level and
+	// only entries for which it returns true are written. The entry's
+	// fields must not be modified.
+	Filter func(entry Entry) bool
+
+	// Async, when set, writes the sink's entries from a background
+	// goroutine as described for Options.Async.
+	Async *AsyncOptions
+}
+
+// SetSinks routes the entries of the default logger and the loggers derived
+// from it to the given sinks. Calling SetSinks without sinks restores
+// writing to the logger's output.
+func SetSinks(sinks ...Sink) {
+	defaultLogger.Load().sinks.Store(newSinks(sinks))
+}
+
+// sink is a configured Sink
+type sink struct {
+	out     *syncWriter
+	level   logLevel
+	encoder Encoder
+	filter  func(entry Entry) bool
+}
+
+// newSinks converts sinks to their internal form, or returns nil if there
+// are none
+func newSinks(sinks []Sink) []*sink {
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	configured := make([]*sink, 0, len(sinks))
+	for _, s := range sinks {
+		output := s.Output
+		if output == nil {
+			output = os.Stdout
+		}
+
+		c := &sink{out: newSyncWriter(output), filter: s.Filter, encoder: s.Encoder}
+		if s.Level != "" {
+			c.level = parseLevel(s.Level)
+		}
+		if c.encoder == nil && s.Format != "" {
+			if enc, ok := parseFormat(s.Format); ok {
+				c.encoder = enc
+			}
+		}
+		if s.Async != nil {
+			c.out.SetAsync(s.Async)
+		}
+
+		configured = append(configured, c)
+	}
+
+	return configured
+}
+
+// accepts reports whether the sink writes the entry
+func (s *sink) accepts(level logLevel, entry Entry) bool {
+	if s.level != "" && !levelEnabled(level, s.level) {
+		return false
+	}
+	return s.filter == nil || s.filter(entry)
+}
+
+// sinksVar holds the sinks of a logger, which can be read and changed
+// concurrently. Child loggers share the sinksVar of their parent.
+type sinksVar struct {
+	value atomic.Pointer[[]*sink]
+}
+
+// Load returns the current sinks, or nil if entries are written to the
+// logger's output
+func (v *sinksVar) Load() []*sink {
+	if sinks := v.value.Load(); sinks != nil {
+		return *sinks
+	}
+	return nil
+}
+
+// Store atomically replaces the current sinks, flushing and stopping the
+// background writers of the previous ones
+func (v *sinksVar) Store(sinks []*sink) {
+	var prev *[]*sink
+	if sinks == nil {
+		prev = v.value.Swap(nil)
+	} else {
+		prev = v.value.Swap(&sinks)
+	}
+
+	if prev != nil {
+		for _, s := range *prev {
+			_ = s.out.Close()
+		}
+	}
+}
+
+// writeSinks encodes the entry for each sink that accepts it
+func (l *jsonLogger) writeSinks(sinks []*sink, level logLevel, entry Entry) {
+	for _, s := range sinks {
+		if !s.accepts(level, entry) {
+			continue
+		}
+
+		enc := s.encoder
+		if enc == nil {
+			enc = l.encoder.Load()
+		}
+
+		data, err := enc.Encode(entry)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling log entry: %v\n", err)
+			continue
+		}
+
+		if _, err := s.out.Write(append(data, '\n')); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing log entry: %v\n", err)
+		}
+	}
+}
+
+// flush waits until the queued entries of the output and every sink have
+// been written
+func (l *jsonLogger) flush() error {
+	errs := []error{l.out.Flush()}
+	for _, s := range l.sinks.Load() {
+		errs = append(errs, s.out.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// close flushes the output and every sink and stops their background writers
+func (l *jsonLogger) close() error {
+	errs := []error{l.out.Close()}
+	for _, s := range l.sinks.Load() {
+		errs = append(errs, s.out.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSinksRouteEntriesByLevel(t *testing.T) {
+	// Given
+	stdout := &lockedBuffer{}
+	stderr := &lockedBuffer{}
+	logger := New(Options{
+		Level: "debug",
+		Sinks: []Sink{
+			{Output: stdout, Level: "info"},
+			{Output: stderr, Level: "error"},
+		},
+	})
+
+	// When
+	logger.Debugf("debug message", nil)
+	logger.Infof("info message", nil)
+	logger.Errorf("error message", nil)
+
+	// Then
+	stdoutEntries := stdout.entries(t)
+	if len(stdoutEntries) != 2 || stdoutEntries[0].Message != "info message" || stdoutEntries[1].Message != "error message" {
+		t.Errorf("stdout sink got %+v, want the INFO and ERROR entries", stdoutEntries)
+	}
+
+	stderrEntries := stderr.entries(t)
+	if len(stderrEntries) != 1 || stderrEntries[0].Message != "error message" {
+		t.Errorf("stderr sink got %+v, want only the ERROR entry", stderrEntries)
+	}
+}
+
+func TestSinksUseTheirOwnFormatAndFilter(t *testing.T) {
+	// Given
+	jsonOut := &lockedBuffer{}
+	logfmtOut := &lockedBuffer{}
+	logger := New(Options{
+		Sinks: []Sink{
+			{Output: jsonOut},
+			{
+				Output: logfmtOut,
+				Format: FormatLogfmt,
+				Filter: func(entry Entry) bool {
+					return entry.Fields["audit"] == true
+				},
+			},
+		},
+	})
+
+	// When
+	logger.Infof("regular", nil)
+	logger.Infof("audited", map[string]any{"audit": true})
+
+	// Then
+	if got := len(jsonOut.entries(t)); got != 2 {
+		t.Errorf("JSON sink got %d entries, want 2", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logfmtOut.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "msg=audited audit=true") {
+		t.Errorf("logfmt sink got %q, want only the audited entry", lines)
+	}
+}
+
+func TestSetSinksAppliesToDerivedLoggersAndCanBeReset(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	out := &lockedBuffer{}
+	sinkOut := &lockedBuffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(out)
+	defaultLogger.Store(testLogger)
+
+	child := With(map[string]any{"component": "worker"})
+
+	// When
+	SetSinks(Sink{Output: sinkOut})
+	child.Infof("to sink", nil)
+	SetSinks()
+	child.Infof("to output", nil)
+
+	// Then
+	if entries := sinkOut.entries(t); len(entries) != 1 || entries[0].Message != "to sink" {
+		t.Errorf("Sink got %+v, want the first entry", entries)
+	}
+	if entries := out.entries(t); len(entries) != 1 || entries[0].Message != "to output" {
+		t.Errorf("Output got %+v, want the second entry", entries)
+	}
+}
+
+func TestFlushWritesQueuedSinkEntries(t *testing.T) {
+	// Given
+	out := &lockedBuffer{}
+	logger := New(Options{Sinks: []Sink{{Output: out, Async: &AsyncOptions{}}}})
+	defer logger.Close()
+
+	// When
+	for range 10 {
+		logger.Warnf("queued", nil)
+	}
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	// Then
+	if got := len(out.entries(t)); got != 10 {
+		t.Errorf("Sink got %d entries, want 10", got)
+	}
+}
@@ -0,0 +1,192 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Levels used when entries are exchanged with log/slog. TRACE, PANIC and
+// FATAL have no slog equivalent, so TRACE is placed one step below DEBUG,
+// and PANIC and FATAL between ERROR and the next step above it.
+const (
+	LevelTrace = slog.LevelDebug - 4
+	LevelPanic = slog.LevelError + 2
+	LevelFatal = slog.LevelError + 4
+)
+
+// slogHandler is a slog.Handler that writes records through a jsonLogger,
+// so records logged with log/slog produce the same entries as pkg/log
+type slogHandler struct {
+	logger *jsonLogger
+	group  string
+}
+
+// Handler returns a slog.Handler that writes records through the logger.
+// Attributes become entry fields, and groups are flattened into dotted keys
+// such as "http.request.method". Records at or above slog.LevelError are
+// logged as ERROR; a slog record never terminates the program.
+//
+// Example:
+//
+//	slog.SetDefault(slog.New(log.Default().Handler()))
+func (l *Logger) Handler() slog.Handler {
+	return &slogHandler{logger: l.logger()}
+}
+
+// SetHandler routes every entry of the default logger, and of loggers derived
+// from it, to the given slog.Handler. Passing nil restores JSON output.
+func SetHandler(handler slog.Handler) {
+	defaultLogger.Load().handler.Store(handler)
+}
+
+// SetSlogDefault makes the default logger the backend of log/slog's default
+// logger, so packages that log through slog (such as the logger middleware
+// and the ginhttp client) produce the same entries as pkg/log.
+func SetSlogDefault() {
+	slog.SetDefault(slog.New(Default().Handler()))
+}
+
+// Enabled reports whether the logger would write an entry at the given level
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.shouldLog(fromSlogLevel(level))
+}
+
+// Handle writes the record through the logger, adding the trace, span and
+// user IDs found in ctx
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var fields map[string]any
+	if record.NumAttrs() > 0 {
+		fields = make(map[string]any, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			addAttr(fields, h.group, attr)
+			return true
+		})
+	}
+
+	h.logger.logCtx(ctx, fromSlogLevel(record.Level), record.Message, fields)
+	return nil
+}
+
+// WithAttrs returns a handler whose entries include the given attributes
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		addAttr(fields, h.group, attr)
+	}
+
+	return &slogHandler{logger: h.logger.with(fields), group: h.group}
+}
+
+// WithGroup returns a handler that prefixes the keys of subsequent
+// attributes with the group name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{logger: h.logger, group: groupKey(h.group, name)}
+}
+
+// addAttr adds the attribute to fields, flattening groups into dotted keys
+func addAttr(fields map[string]any, group string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		// Inline groups with an empty key into the current group
+		prefix := group
+		if attr.Key != "" {
+			prefix = groupKey(group, attr.Key)
+		}
+
+		for _, groupAttr := range value.Group() {
+			addAttr(fields, prefix, groupAttr)
+		}
+		return
+	}
+
+	// Ignore empty attributes as slog's built-in handlers do
+	if attr.Key == "" {
+		return
+	}
+
+	fields[groupKey(group, attr.Key)] = slogValue(value)
+}
+
+// slogValue converts a resolved slog.Value to a JSON-friendly value
+func slogValue(value slog.Value) any {
+	switch value.Kind() {
+	case slog.KindDuration:
+		return value.Duration().String()
+	case slog.KindTime:
+		return value.Time().Format(time.RFC3339)
+	default:
+		return value.Any()
+	}
+}
+
+// groupKey joins a group prefix and a key with a dot
+func groupKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
+
+// fromSlogLevel maps a slog.Level to the closest logLevel. Levels above
+// ERROR are capped at ERROR so that slog records never exit the program.
+func fromSlogLevel(level slog.Level) logLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return tracelevel
+	case level < slog.LevelInfo:
+		return debuglevel
+	case level < slog.LevelWarn:
+		return infolevel
+	case level < slog.LevelError:
+		return warnlevel
+	default:
+		return errorlevel
+	}
+}
+
+// toSlogLevel maps a logLevel to its slog.Level
+func toSlogLevel(level logLevel) slog.Level {
+	switch level {
+	case tracelevel:
+		return LevelTrace
+	case debuglevel:
+		return slog.LevelDebug
+	case warnlevel:
+		return slog.LevelWarn
+	case errorlevel:
+		return slog.LevelError
+	case paniclevel:
+		return LevelPanic
+	case fatallevel:
+		return LevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// handleRecord sends an entry to a slog.Handler as a slog.Record, with
+// fields converted to attributes in key order
+func handleRecord(handler slog.Handler, level logLevel, msg string, fields map[string]any) error {
+	ctx := context.Background()
+	slogLevel := toSlogLevel(level)
+	if !handler.Enabled(ctx, slogLevel) {
+		return nil
+	}
+
+	record := slog.NewRecord(time.Now(), slogLevel, msg, 0)
+	for _, k := range sortedKeys(fields) {
+		record.AddAttrs(slog.Any(k, fields[k]))
+	}
+
+	return handler.Handle(ctx, record)
+}
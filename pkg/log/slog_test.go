@@ -0,0 +1,181 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHandlerWritesLogEntryFormat(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, Fields: map[string]any{"service": "api"}})
+	slogger := slog.New(logger.Handler())
+
+	// When
+	slogger.Warn("incoming request warning",
+		"http.response.status_code", 404,
+		"http.response.latency", 125*time.Millisecond,
+		slog.Group("server", "address", "localhost:8080"),
+	)
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	if entry.Level != "WARN" || entry.Message != "incoming request warning" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	fields := *entry.Fields
+	expectedFields := map[string]any{
+		"service":                   "api",
+		"http.response.status_code": float64(404),
+		"http.response.latency":     "125ms",
+		"server.address":            "localhost:8080",
+	}
+
+	for k, v := range expectedFields {
+		if fields[k] != v {
+			t.Errorf("Field %s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
+
+func TestHandlerWithAttrsAndGroup(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+	handler := logger.Handler().WithAttrs([]slog.Attr{slog.String("trace_id", "abc")}).WithGroup("db")
+
+	// When
+	slog.New(handler).Info("query completed", "rows", 3)
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	fields := *entry.Fields
+	if fields["trace_id"] != "abc" || fields["db.rows"] != float64(3) {
+		t.Errorf("Unexpected fields: %+v", fields)
+	}
+}
+
+func TestHandlerRespectsLoggerLevel(t *testing.T) {
+	testCases := []struct {
+		level    slog.Level
+		expected bool
+	}{
+		{LevelTrace, false},
+		{slog.LevelDebug, false},
+		{slog.LevelInfo, false},
+		{slog.LevelWarn, true},
+		{slog.LevelError, true},
+		{LevelFatal, true},
+	}
+
+	logger := New(Options{Level: "WARN", Output: &bytes.Buffer{}})
+	handler := logger.Handler()
+
+	for _, tc := range testCases {
+		t.Run(tc.level.String(), func(t *testing.T) {
+			// When
+			enabled := handler.Enabled(t.Context(), tc.level)
+
+			// Then
+			if enabled != tc.expected {
+				t.Errorf("Enabled(%s) = %v, want %v", tc.level, enabled, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLoggerRoutesEntriesToHandler(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	logger := New(Options{
+		Level:   "TRACE",
+		Handler: slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: LevelTrace}),
+		Fields:  map[string]any{"service": "api"},
+	})
+
+	// When
+	logger.Errorf("request failed", map[string]any{"status": 500})
+
+	// Then
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to unmarshal slog record: %v\nOutput was: %s", err, buf.String())
+	}
+
+	if record["level"] != "ERROR" || record["msg"] != "request failed" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+
+	if record["service"] != "api" || record["status"] != float64(500) {
+		t.Errorf("Expected fields as attributes, got %+v", record)
+	}
+}
+
+func TestSetHandlerRoutesDefaultLogger(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	testLogger := newJSONLogger(infolevel)
+	jsonBuf := &bytes.Buffer{}
+	testLogger.out.SetOutput(jsonBuf)
+	defaultLogger.Store(testLogger)
+
+	slogBuf := &bytes.Buffer{}
+	child := With(map[string]any{"child": true})
+
+	// When
+	SetHandler(slog.NewTextHandler(slogBuf, nil))
+	child.Infof("routed message", nil)
+	SetHandler(nil)
+	Infof("json message", nil)
+
+	// Then
+	if !bytes.Contains(slogBuf.Bytes(), []byte("msg=\"routed message\" child=true")) {
+		t.Errorf("Expected child entry in slog output, got: %s", slogBuf.String())
+	}
+
+	if !bytes.Contains(jsonBuf.Bytes(), []byte("json message")) || bytes.Contains(jsonBuf.Bytes(), []byte("routed message")) {
+		t.Errorf("Unexpected JSON output: %s", jsonBuf.String())
+	}
+}
+
+func TestSetSlogDefaultUnifiesOutput(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	origSlog := slog.Default()
+	defer slog.SetDefault(origSlog)
+
+	buf := &bytes.Buffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	// When
+	SetSlogDefault()
+	slog.Info("outgoing request completed", "trace_id", "abc")
+
+	// Then
+	var entry testLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v\nOutput was: %s", err, buf.String())
+	}
+
+	if entry.Message != "outgoing request completed" || (*entry.Fields)["trace_id"] != "abc" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+}
@@ -0,0 +1,313 @@
+package log
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// levelVar holds a logLevel, and the level overrides of named loggers, that
+// can be read and changed concurrently. Child loggers share the levelVar of
+// their parent, so changing the level of a logger also changes the level of
+// every logger derived from it.
+type levelVar struct {
+	value     atomic.Value
+	overrides atomic.Pointer[map[string]logLevel]
+
+	// mu serializes changes so that pending reverts of temporary levels
+	// restore the right level
+	mu      sync.Mutex
+	reverts map[string]*levelRevert
+
+	// now and afterFunc schedule the reverts, and are replaced in tests
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) (stop func() bool)
+}
+
+// levelRevert restores a level, or removes an override, once a temporary
+// level expires
+type levelRevert struct {
+	stop  func() bool
+	at    time.Time
+	level logLevel
+}
+
+// newLevelVar creates a levelVar set to the given level
+func newLevelVar(level logLevel) *levelVar {
+	v := &levelVar{
+		now: time.Now,
+		afterFunc: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+	}
+	v.Store(level)
+	return v
+}
+
+// Load returns the current level
+func (v *levelVar) Load() logLevel {
+	level, _ := v.value.Load().(logLevel)
+	return level
+}
+
+// Store atomically replaces the current level, cancelling any pending revert
+func (v *levelVar) Store(level logLevel) {
+	v.set("", level, 0)
+}
+
+// For returns the level of the named logger: the override for the longest
+// dot-separated prefix of name, such as "db" for "db.pool", or the current
+// level if there is none
+func (v *levelVar) For(name string) logLevel {
+	overrides := v.overrides.Load()
+	if name == "" || overrides == nil {
+		return v.Load()
+	}
+
+	for {
+		if level, ok := (*overrides)[name]; ok {
+			return level
+		}
+
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return v.Load()
+		}
+		name = name[:i]
+	}
+}
+
+// set changes the level, or the override of the named logger, for ttl. An
+// empty level removes the override. A ttl of zero makes the change
+// permanent, otherwise the previous level is restored once ttl has elapsed.
+func (v *levelVar) set(name string, level logLevel, ttl time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Changes made while a temporary level is active restore the level that
+	// was set before it
+	restore := v.current(name)
+	if r, ok := v.reverts[name]; ok {
+		r.stop()
+		restore = r.level
+		delete(v.reverts, name)
+	}
+
+	v.apply(name, level)
+	if ttl <= 0 {
+		return
+	}
+
+	r := &levelRevert{at: v.now().Add(ttl), level: restore}
+	r.stop = v.afterFunc(ttl, func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		if v.reverts[name] == r {
+			delete(v.reverts, name)
+			v.apply(name, r.level)
+		}
+	})
+
+	if v.reverts == nil {
+		v.reverts = make(map[string]*levelRevert)
+	}
+	v.reverts[name] = r
+}
+
+// current returns the level, or the override of the named logger
+func (v *levelVar) current(name string) logLevel {
+	if name == "" {
+		return v.Load()
+	}
+
+	if overrides := v.overrides.Load(); overrides != nil {
+		return (*overrides)[name]
+	}
+	return ""
+}
+
+// apply stores the level, or replaces the overrides copy-on-write
+func (v *levelVar) apply(name string, level logLevel) {
+	if name == "" {
+		v.value.Store(level)
+		return
+	}
+
+	overrides := make(map[string]logLevel)
+	if current := v.overrides.Load(); current != nil {
+		for k, l := range *current {
+			overrides[k] = l
+		}
+	}
+
+	if level == "" {
+		delete(overrides, name)
+	} else {
+		overrides[name] = level
+	}
+
+	if len(overrides) == 0 {
+		v.overrides.Store(nil)
+		return
+	}
+	v.overrides.Store(&overrides)
+}
+
+// revertAt returns when the temporary level, or override of the named
+// logger, expires, or the zero time if it is permanent
+func (v *levelVar) revertAt(name string) time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if r, ok := v.reverts[name]; ok {
+		return r.at
+	}
+	return time.Time{}
+}
+
+// handlerVar holds an optional slog.Handler that can be read and changed
+// concurrently. Child loggers share the handlerVar of their parent.
+type handlerVar struct {
+	value atomic.Pointer[slog.Handler]
+}
+
+// Load returns the current handler, or nil if entries are written as JSON
+func (v *handlerVar) Load() slog.Handler {
+	if h := v.value.Load(); h != nil {
+		return *h
+	}
+	return nil
+}
+
+// Store atomically replaces the current handler. A nil handler restores
+// JSON output.
+func (v *handlerVar) Store(handler slog.Handler) {
+	if handler == nil {
+		v.value.Store(nil)
+		return
+	}
+	v.value.Store(&handler)
+}
+
+// samplerVar holds an optional sampler that can be read and changed
+// concurrently. Child loggers share the samplerVar of their parent.
+type samplerVar struct {
+	value atomic.Pointer[sampler]
+}
+
+// Load returns the current sampler, or nil if sampling is disabled
+func (v *samplerVar) Load() *sampler {
+	return v.value.Load()
+}
+
+// Store atomically replaces the current sampler
+func (v *samplerVar) Store(s *sampler) {
+	v.value.Store(s)
+}
+
+// redactorVar holds an optional Redactor that can be read and changed
+// concurrently. Child loggers share the redactorVar of their parent.
+type redactorVar struct {
+	value atomic.Pointer[Redactor]
+}
+
+// Load returns the current redactor, or nil if redaction is disabled
+func (v *redactorVar) Load() *Redactor {
+	return v.value.Load()
+}
+
+// Store atomically replaces the current redactor
+func (v *redactorVar) Store(r *Redactor) {
+	v.value.Store(r)
+}
+
+// encoderVar holds the Encoder used to format entries, which can be read and
+// changed concurrently. Child loggers share the encoderVar of their parent.
+type encoderVar struct {
+	value atomic.Pointer[Encoder]
+}
+
+// Load returns the current encoder
+func (v *encoderVar) Load() Encoder {
+	return *v.value.Load()
+}
+
+// Store atomically replaces the current encoder. A nil encoder restores
+// JSON output.
+func (v *encoderVar) Store(enc Encoder) {
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	v.value.Store(&enc)
+}
+
+// syncWriter serializes writes to the underlying writer so that entries
+// written from different goroutines never interleave. In async mode entries
+// are queued and written by a background goroutine instead. Child loggers
+// share the syncWriter of their parent.
+type syncWriter struct {
+	mu    sync.Mutex
+	out   io.Writer
+	async atomic.Pointer[asyncWriter]
+}
+
+// newSyncWriter creates a syncWriter around out
+func newSyncWriter(out io.Writer) *syncWriter {
+	return &syncWriter{out: out}
+}
+
+// Write writes p to the underlying writer while holding the lock, or queues
+// it in async mode. p must not be modified after the call.
+func (w *syncWriter) Write(p []byte) (int, error) {
+	if async := w.async.Load(); async != nil && async.enqueue(p) {
+		return len(p), nil
+	}
+	return w.writeSync(p)
+}
+
+// writeSync writes p to the underlying writer while holding the lock
+func (w *syncWriter) writeSync(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.Write(p)
+}
+
+// SetOutput replaces the underlying writer once pending writes complete
+func (w *syncWriter) SetOutput(out io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.out = out
+}
+
+// SetAsync starts a background writer with the given options, replacing and
+// flushing the current one. Passing nil restores synchronous writes.
+func (w *syncWriter) SetAsync(opts *AsyncOptions) {
+	var async *asyncWriter
+	if opts != nil {
+		async = newAsyncWriter(*opts, w.writeSync)
+	}
+
+	if prev := w.async.Swap(async); prev != nil {
+		_ = prev.Close()
+	}
+}
+
+// Flush waits until queued entries have been written
+func (w *syncWriter) Flush() error {
+	if async := w.async.Load(); async != nil {
+		return async.Flush()
+	}
+	return nil
+}
+
+// Close flushes queued entries and restores synchronous writes
+func (w *syncWriter) Close() error {
+	if async := w.async.Swap(nil); async != nil {
+		return async.Close()
+	}
+	return nil
+}
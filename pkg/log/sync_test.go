@@ -0,0 +1,148 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSettersAndLoggers(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	testLogger := newJSONLogger(tracelevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	child := With(map[string]any{"child": true})
+	levels := []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}
+
+	const goroutines = 8
+	const iterations = 200
+
+	// When - setters and loggers run against the same logger at once
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(5)
+
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				SetFields(map[string]any{fmt.Sprintf("key-%d", g): i})
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				SetLevel(levels[(g+i)%len(levels)])
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				SetOutput(buf)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				Errorf("package message", map[string]any{"iteration": i})
+				child.Errorf("child message", map[string]any{"iteration": i})
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				With(map[string]any{"goroutine": g}).Errorf("derived message", nil)
+				SetDefault(&Logger{core: testLogger})
+				_ = Default().With(map[string]any{"iteration": i})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Then - every ERROR entry was written as a complete JSON line
+	assertCompleteJSONLines(t, buf, goroutines*iterations*3)
+}
+
+func TestConcurrentChildLoggersShareOutput(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	parent := New(Options{Output: buf})
+
+	const goroutines = 16
+	const iterations = 100
+
+	// When
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child := parent.With(map[string]any{"goroutine": g})
+			for i := range iterations {
+				child.Infof("child message", map[string]any{"iteration": i})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Then
+	assertCompleteJSONLines(t, buf, goroutines*iterations)
+}
+
+func TestSetLevelAppliesToChildLoggers(t *testing.T) {
+	// Given
+	origLogger := defaultLogger.Load()
+	defer defaultLogger.Store(origLogger)
+
+	buf := &bytes.Buffer{}
+	testLogger := newJSONLogger(infolevel)
+	testLogger.out.SetOutput(buf)
+	defaultLogger.Store(testLogger)
+
+	child := With(map[string]any{"request_id": "req-123"})
+
+	// When
+	SetLevel("ERROR")
+	child.Warnf("filtered message", nil)
+
+	// Then
+	if buf.Len() != 0 {
+		t.Errorf("Expected child to use the updated level, got output: %s", buf.String())
+	}
+}
+
+// assertCompleteJSONLines checks that r contains at least want lines and that
+// every line is a valid JSON log entry
+func assertCompleteJSONLines(t *testing.T, r io.Reader, want int) {
+	t.Helper()
+
+	count := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry testLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Interleaved or corrupt log line %q: %v", scanner.Text(), err)
+		}
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read log output: %v", err)
+	}
+
+	if count < want {
+		t.Errorf("Got %d log lines, want at least %d", count, want)
+	}
+}
@@ -1,6 +1,10 @@
 package log
 
-import "io"
+import (
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
 
 type logLevel string
 
@@ -10,15 +14,86 @@ const (
 	infolevel  logLevel = "INFO"
 	warnlevel  logLevel = "WARN"
 	errorlevel logLevel = "ERROR"
+	paniclevel logLevel = "PANIC"
 	fatallevel logLevel = "FATAL"
 )
 
-// jsonLogger is the internal logger implementation
+// Logger is a structured logger. Loggers are created with New or derived
+// from an existing logger with With, and are safe to pass between goroutines.
+// A nil or zero Logger writes through the default logger.
+type Logger struct {
+	core *jsonLogger
+}
+
+// Options configures a Logger created with New
+type Options struct {
+	// Level is the minimum level that will be logged. Defaults to INFO.
+	Level string
+
+	// Output is the destination for log entries. Defaults to os.Stdout.
+	Output io.Writer
+
+	// Format selects the output format: FormatJSON, FormatLogfmt or
+	// FormatConsole. Defaults to the LOG_FORMAT environment variable, or
+	// JSON if it is unset.
+	Format string
+
+	// Encoder, when set, formats entries instead of Format.
+	Encoder Encoder
+
+	// Name identifies the logger for level overrides and is added to every
+	// entry as the "logger" field.
+	Name string
+
+	// Fields are included in every entry written by the logger.
+	Fields map[string]any
+
+	// Handler, when set, receives every entry as a slog.Record instead of
+	// the entry being written to Output as JSON.
+	Handler slog.Handler
+
+	// Sampling, when set, limits how many entries with the same level and
+	// message are written per interval.
+	Sampling *SamplingOptions
+
+	// Sinks, when set, receive the logger's entries instead of Output, each
+	// with its own level, format and filter.
+	Sinks []Sink
+
+	// Async, when set, queues entries in a bounded buffer that is written to
+	// Output by a background goroutine. Call Flush or Close before exiting.
+	Async *AsyncOptions
+
+	// AddCaller records the caller's file:line as the "caller" field.
+	AddCaller bool
+
+	// StackTrace records a stack trace as the "stacktrace" field of ERROR,
+	// PANIC and FATAL entries.
+	StackTrace bool
+
+	// Redactor masks sensitive field values before entries are written.
+	// Defaults to DefaultRedactor; use NewRedactor() without patterns to
+	// only redact tagged struct fields.
+	Redactor *Redactor
+}
+
+// jsonLogger is the internal logger implementation. Its level, output,
+// encoder, sinks, handler, sampler, redactor and caller settings are shared
+// with derived loggers, while fields are replaced copy-on-write so that log
+// calls can read them without locking.
 type jsonLogger struct {
-	level       logLevel
-	out         io.Writer
-	initialized bool
-	fields      map[string]any
+	level       *levelVar
+	out         *syncWriter
+	handler     *handlerVar
+	sampler     *samplerVar
+	redactor    *redactorVar
+	encoder     *encoderVar
+	sinks       *sinksVar
+	caller      *atomic.Bool
+	stacktrace  *atomic.Bool
+	name        string
+	initialized atomic.Bool
+	fields      atomic.Pointer[map[string]any]
 }
 
 // logEntry represents a single log message structure
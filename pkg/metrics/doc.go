@@ -0,0 +1,28 @@
+// Package metrics provides counters, gauges and histograms served in the
+// Prometheus text exposition format, without depending on a metrics client
+// library or service.
+//
+// Basic Usage:
+//
+//	jobs := metrics.Default().Counter("jobs_processed_total", "Jobs processed.", "queue")
+//	duration := metrics.Default().Histogram("job_duration_seconds", "Job duration.", nil, "queue")
+//
+//	jobs.Inc("emails")
+//	duration.Observe(time.Since(start).Seconds(), "emails")
+//
+//	http.Handle("/metrics", metrics.Default().Handler())
+//
+// Registries:
+// Metrics are registered on first use and the same metric is returned when
+// registered again with the same type and labels, so packages can look up
+// their metrics without coordinating. Registering a name with another type or
+// other labels panics, as does passing the wrong number of label values.
+//
+// Default returns the registry used by the metrics middleware and the ginhttp
+// client. Separate registries created with NewRegistry are useful in tests.
+//
+// Labels:
+// Every combination of label values is a separate series kept in memory, so
+// label values must come from small sets, such as route templates rather
+// than request paths.
+package metrics
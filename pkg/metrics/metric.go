@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metric types, as written in the text exposition format
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// labelSeparator joins label values into series keys. It cannot appear in
+// valid UTF-8 label values.
+const labelSeparator = "\xff"
+
+// DefaultLatencyBuckets are histogram buckets for durations in seconds
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are histogram buckets for sizes in bytes
+var DefaultSizeBuckets = []float64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
+
+// StatusClass returns the class of an HTTP status code, such as "2xx", for
+// use as a label value. Invalid codes give "unknown".
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// atomicFloat is a float64 updated atomically
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+// Add adds delta to the value
+func (f *atomicFloat) Add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Store sets the value
+func (f *atomicFloat) Store(value float64) {
+	f.bits.Store(math.Float64bits(value))
+}
+
+// Load returns the value
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// family holds the series of a metric, one per combination of label values
+type family[S any] struct {
+	name     string
+	help     string
+	kind     string
+	labels   []string
+	newValue func() *S
+
+	mu     sync.RWMutex
+	series map[string]*S
+}
+
+// with returns the series for the label values, creating it on first use
+func (f *family[S]) with(values []string) *S {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values %v, got %d", f.name, len(f.labels), f.labels, len(values)))
+	}
+
+	key := strings.Join(values, labelSeparator)
+
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok = f.series[key]; !ok {
+		s = f.newValue()
+		f.series[key] = s
+	}
+	return s
+}
+
+// sortedSeries returns the series keys and series sorted by label values,
+// so that the exposition output is stable
+func (f *family[S]) sortedSeries() ([]string, []*S) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	keys := slices.Sorted(maps.Keys(f.series))
+	series := make([]*S, len(keys))
+	for i, key := range keys {
+		series[i] = f.series[key]
+	}
+	return keys, series
+}
+
+// labelValues splits a series key into label values
+func (f *family[S]) labelValues(key string) []string {
+	if len(f.labels) == 0 {
+		return nil
+	}
+	return strings.Split(key, labelSeparator)
+}
+
+// definition returns the name, help, type and labels identifying the family
+func (f *family[S]) definition() (string, string, string, []string) {
+	return f.name, f.help, f.kind, f.labels
+}
+
+// CounterVec is a counter partitioned by labels. Counters only increase.
+type CounterVec struct {
+	family[atomicFloat]
+}
+
+// Inc adds one to the counter of the label values
+func (v *CounterVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the counter of the label
+// values
+func (v *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", v.name))
+	}
+	v.with(labelValues).Add(delta)
+}
+
+// Value returns the counter of the label values
+func (v *CounterVec) Value(labelValues ...string) float64 {
+	return v.with(labelValues).Load()
+}
+
+// GaugeVec is a gauge partitioned by labels. Gauges go up and down.
+type GaugeVec struct {
+	family[atomicFloat]
+}
+
+// Set sets the gauge of the label values
+func (v *GaugeVec) Set(value float64, labelValues ...string) {
+	v.with(labelValues).Store(value)
+}
+
+// Add adds delta to the gauge of the label values
+func (v *GaugeVec) Add(delta float64, labelValues ...string) {
+	v.with(labelValues).Add(delta)
+}
+
+// Inc adds one to the gauge of the label values
+func (v *GaugeVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Dec subtracts one from the gauge of the label values
+func (v *GaugeVec) Dec(labelValues ...string) {
+	v.Add(-1, labelValues...)
+}
+
+// Value returns the gauge of the label values
+func (v *GaugeVec) Value(labelValues ...string) float64 {
+	return v.with(labelValues).Load()
+}
+
+// histogram counts observations in buckets
+type histogram struct {
+	upperBounds []float64
+	buckets     []atomic.Uint64
+	count       atomic.Uint64
+	sum         atomicFloat
+}
+
+// observe adds an observation
+func (h *histogram) observe(value float64) {
+	// Buckets are stored non-cumulatively and summed when written. Values
+	// above the last bound are only counted by the +Inf bucket.
+	if i, _ := slices.BinarySearch(h.upperBounds, value); i < len(h.buckets) {
+		h.buckets[i].Add(1)
+	}
+	h.count.Add(1)
+	h.sum.Add(value)
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	family[histogram]
+}
+
+// Observe adds an observation to the histogram of the label values
+func (v *HistogramVec) Observe(value float64, labelValues ...string) {
+	v.with(labelValues).observe(value)
+}
+
+// Count returns the number of observations and their sum for the label
+// values
+func (v *HistogramVec) Count(labelValues ...string) (uint64, float64) {
+	h := v.with(labelValues)
+	return h.count.Load(), h.sum.Load()
+}
@@ -0,0 +1,267 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// collector is a metric family that can be written in the text exposition
+// format
+type collector interface {
+	definition() (string, string, string, []string)
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics and writes them in the Prometheus text exposition
+// format
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// defaultRegistry is used by the metrics middleware and the ginhttp client
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default returns the registry shared by golib packages
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Counter returns the counter registered under name, registering it on first
+// use. It panics if name is registered as another type or with other labels.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	return register(r, name, help, typeCounter, labels, func() *CounterVec {
+		v := &CounterVec{}
+		v.init(name, help, typeCounter, labels, func() *atomicFloat { return &atomicFloat{} })
+		return v
+	})
+}
+
+// Gauge returns the gauge registered under name, registering it on first
+// use. It panics if name is registered as another type or with other labels.
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	return register(r, name, help, typeGauge, labels, func() *GaugeVec {
+		v := &GaugeVec{}
+		v.init(name, help, typeGauge, labels, func() *atomicFloat { return &atomicFloat{} })
+		return v
+	})
+}
+
+// Histogram returns the histogram registered under name, registering it on
+// first use with the bucket upper bounds, DefaultLatencyBuckets if empty. It
+// panics if name is registered as another type or with other labels.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	upperBounds := slices.Clone(buckets)
+	slices.Sort(upperBounds)
+	upperBounds = slices.Compact(upperBounds)
+	if n := len(upperBounds); n > 0 && math.IsInf(upperBounds[n-1], 1) {
+		// The +Inf bucket is always written
+		upperBounds = upperBounds[:n-1]
+	}
+
+	return register(r, name, help, typeHistogram, labels, func() *HistogramVec {
+		v := &HistogramVec{}
+		v.init(name, help, typeHistogram, labels, func() *histogram {
+			return &histogram{
+				upperBounds: upperBounds,
+				buckets:     make([]atomic.Uint64, len(upperBounds)),
+			}
+		})
+		return v
+	})
+}
+
+// register returns the collector registered under name, or registers the
+// one returned by create
+func register[C collector](r *Registry, name, help, kind string, labels []string, create func() C) C {
+	if !metricNamePattern.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !labelNamePattern.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", label, name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.collectors[name]; ok {
+		_, _, existingKind, existingLabels := existing.definition()
+		c, ok := existing.(C)
+		if !ok || existingKind != kind || !slices.Equal(existingLabels, labels) {
+			panic(fmt.Sprintf("metrics: %s is already registered as a %s with labels %v", name, existingKind, existingLabels))
+		}
+		return c
+	}
+
+	c := create()
+	r.collectors[name] = c
+	return c
+}
+
+// WriteText writes every metric in the text exposition format, sorted by
+// name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, len(names))
+	slices.Sort(names)
+	for i, name := range names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		name, help, kind, _ := c.definition()
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, kind)
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler returns an HTTP handler serving the metrics in the text exposition
+// format, to be scraped by Prometheus
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// init sets the definition of the family
+func (f *family[S]) init(name, help, kind string, labels []string, newValue func() *S) {
+	f.name = name
+	f.help = help
+	f.kind = kind
+	f.labels = slices.Clone(labels)
+	f.newValue = newValue
+	f.series = make(map[string]*S)
+}
+
+// write writes the counter series
+func (v *CounterVec) write(w *bufio.Writer) {
+	keys, series := v.sortedSeries()
+	for i, key := range keys {
+		writeSample(w, v.name, v.labels, v.labelValues(key), "", "", series[i].Load())
+	}
+}
+
+// write writes the gauge series
+func (v *GaugeVec) write(w *bufio.Writer) {
+	keys, series := v.sortedSeries()
+	for i, key := range keys {
+		writeSample(w, v.name, v.labels, v.labelValues(key), "", "", series[i].Load())
+	}
+}
+
+// write writes the cumulative buckets, sum and count of the histogram series
+func (v *HistogramVec) write(w *bufio.Writer) {
+	keys, series := v.sortedSeries()
+	for i, key := range keys {
+		values, h := v.labelValues(key), series[i]
+
+		// The count is read first so that it is never lower than the
+		// buckets written after concurrent observations
+		count := h.count.Load()
+		var cumulative uint64
+		for j, bound := range h.upperBounds {
+			cumulative += h.buckets[j].Load()
+			writeSample(w, v.name+"_bucket", v.labels, values, "le", formatFloat(bound), float64(min(cumulative, count)))
+		}
+		writeSample(w, v.name+"_bucket", v.labels, values, "le", "+Inf", float64(count))
+		writeSample(w, v.name+"_sum", v.labels, values, "", "", h.sum.Load())
+		writeSample(w, v.name+"_count", v.labels, values, "", "", float64(count))
+	}
+}
+
+// writeSample writes a sample line, with an optional extra label such as le
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, label, values[i])
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// writeLabel writes a label pair with the value escaped
+func writeLabel(w *bufio.Writer, label, value string) {
+	w.WriteString(label)
+	w.WriteString(`="`)
+	w.WriteString(labelValueEscaper.Replace(value))
+	w.WriteByte('"')
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+// escapeHelp escapes a HELP text
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// formatFloat formats a sample value as expected by Prometheus
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
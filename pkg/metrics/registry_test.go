@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteTextExpositionFormat(t *testing.T) {
+	// Given
+	registry := NewRegistry()
+	requests := registry.Counter("requests_total", "Requests served.", "method", "path")
+	inFlight := registry.Gauge("in_flight", "Requests in progress.")
+	latency := registry.Histogram("latency_seconds", "Request latency.", []float64{0.5, 0.1, 1}, "method")
+
+	requests.Inc("GET", "/users")
+	requests.Add(2, "POST", `/say "hi"`+"\n")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.1, "GET")
+	latency.Observe(0.7, "GET")
+	latency.Observe(3, "GET")
+
+	buf := &bytes.Buffer{}
+
+	// When
+	err := registry.WriteText(buf)
+
+	// Then
+	if err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	expected := `# HELP in_flight Requests in progress.
+# TYPE in_flight gauge
+in_flight 1
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="GET",le="0.1"} 2
+latency_seconds_bucket{method="GET",le="0.5"} 2
+latency_seconds_bucket{method="GET",le="1"} 3
+latency_seconds_bucket{method="GET",le="+Inf"} 4
+latency_seconds_sum{method="GET"} 3.85
+latency_seconds_count{method="GET"} 4
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{method="GET",path="/users"} 1
+requests_total{method="POST",path="/say \"hi\"\n"} 2
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestRegisterReturnsExistingMetric(t *testing.T) {
+	// Given
+	registry := NewRegistry()
+	first := registry.Counter("jobs_total", "Jobs run.", "queue")
+
+	// When
+	second := registry.Counter("jobs_total", "Jobs run.", "queue")
+	second.Inc("emails")
+
+	// Then
+	if first != second {
+		t.Errorf("Expected the registered counter to be returned")
+	}
+	if got := first.Value("emails"); got != 1 {
+		t.Errorf("Value = %v, want 1", got)
+	}
+}
+
+func TestRegisterPanicsOnInvalidDefinitions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{
+			name:     "invalid metric name",
+			register: func(r *Registry) { r.Counter("requests-total", "") },
+		},
+		{
+			name:     "reserved label name",
+			register: func(r *Registry) { r.Histogram("latency", "", nil, "le") },
+		},
+		{
+			name: "conflicting type",
+			register: func(r *Registry) {
+				r.Counter("jobs", "")
+				r.Gauge("jobs", "")
+			},
+		},
+		{
+			name: "conflicting labels",
+			register: func(r *Registry) {
+				r.Counter("jobs", "", "queue")
+				r.Counter("jobs", "", "worker")
+			},
+		},
+		{
+			name:     "wrong number of label values",
+			register: func(r *Registry) { r.Counter("jobs", "", "queue").Inc() },
+		},
+		{
+			name:     "decreasing counter",
+			register: func(r *Registry) { r.Counter("jobs", "").Add(-1) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			registry := NewRegistry()
+			defer func() {
+				// Then
+				if recover() == nil {
+					t.Errorf("Expected a panic")
+				}
+			}()
+
+			// When
+			tc.register(registry)
+		})
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	// Given
+	registry := NewRegistry()
+	counter := registry.Counter("ops_total", "", "worker")
+	histogram := registry.Histogram("op_seconds", "", nil)
+
+	// When
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				counter.Inc("a")
+				histogram.Observe(0.01)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Then
+	if got := counter.Value("a"); got != 8000 {
+		t.Errorf("Counter = %v, want 8000", got)
+	}
+	if count, _ := histogram.Count(); count != 8000 {
+		t.Errorf("Histogram count = %d, want 8000", count)
+	}
+}
+
+func TestHandlerServesTextFormat(t *testing.T) {
+	// Given
+	registry := NewRegistry()
+	registry.Counter("up", "Whether the service is up.").Inc()
+	resp := httptest.NewRecorder()
+
+	// When
+	registry.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Then
+	if resp.Code != http.StatusOK {
+		t.Errorf("Status = %d, want 200", resp.Code)
+	}
+	if got := resp.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if !strings.Contains(resp.Body.String(), "\nup 1\n") {
+		t.Errorf("Unexpected body:\n%s", resp.Body.String())
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	testCases := []struct {
+		status   int
+		expected string
+	}{
+		{http.StatusContinue, "1xx"},
+		{http.StatusOK, "2xx"},
+		{http.StatusFound, "3xx"},
+		{http.StatusTooManyRequests, "4xx"},
+		{http.StatusServiceUnavailable, "5xx"},
+		{0, "unknown"},
+		{600, "unknown"},
+	}
+
+	for _, tc := range testCases {
+		// When
+		got := StatusClass(tc.status)
+
+		// Then
+		if got != tc.expected {
+			t.Errorf("StatusClass(%d) = %q, want %q", tc.status, got, tc.expected)
+		}
+	}
+}
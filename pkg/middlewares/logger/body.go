@@ -0,0 +1,268 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"slices"
+	"strings"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultBodyMaxBytes is the body capture limit when BodyConfig.MaxBytes is
+// not set
+const defaultBodyMaxBytes = 4096
+
+// truncatedJSONBody replaces JSON bodies that exceed the capture limit, as
+// their sensitive fields cannot be redacted reliably
+const truncatedJSONBody = "[TRUNCATED JSON NOT LOGGED]"
+
+// BodyConfig configures logging of request and response bodies. Bodies are
+// captured while the handler reads and writes them, so streaming requests
+// and responses are not buffered, and at most MaxBytes of each body are
+// kept.
+type BodyConfig struct {
+	// Request logs request bodies as "http.request.body.content".
+	Request bool
+
+	// Response logs response bodies as "http.response.body.content".
+	Response bool
+
+	// MaxBytes is the number of bytes of each body that are logged. Longer
+	// bodies are truncated and marked with "http.request.body.truncated" or
+	// "http.response.body.truncated". Defaults to 4096.
+	MaxBytes int
+
+	// ContentTypes is the allowlist of media types whose bodies are logged.
+	// Entries ending in "/*" match every subtype, such as "text/*".
+	// Defaults to JSON, form and plain text bodies.
+	ContentTypes []string
+
+	// MinStatus and MaxStatus restrict body logging to responses whose
+	// status is within the range. Zero leaves the bound open.
+	// Example: MinStatus: 400 logs bodies of failed requests only
+	MinStatus int
+	MaxStatus int
+
+	// RedactKeys masks the values of JSON and form fields whose keys match
+	// one of the patterns, as described by log.Redactor, in addition to the
+	// patterns of the default pkg/log redactor.
+	RedactKeys []string
+}
+
+// defaultBodyContentTypes are the media types logged when
+// BodyConfig.ContentTypes is not set
+var defaultBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"text/plain",
+}
+
+// cappedBuffer keeps the first max bytes written to it and records whether
+// more were written
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write implements io.Writer, never failing so that it can be used with
+// io.TeeReader
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - b.buf.Len(); remaining < len(p) {
+		b.truncated = true
+		b.buf.Write(p[:max(remaining, 0)])
+		return len(p), nil
+	}
+
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// bodyReader tees the request body into a cappedBuffer as the handler reads
+// it
+type bodyReader struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyWriter tees the response body into a cappedBuffer if its content type
+// is logged. Flush, Hijack and the other gin.ResponseWriter methods are
+// passed through, so streaming responses keep working.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body    *cappedBuffer
+	allowed func(contentType string) bool
+	checked bool
+	capture bool
+}
+
+// Write writes p to the client and captures it
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.tee(p[:n])
+	return n, err
+}
+
+// WriteString writes s to the client and captures it
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.tee([]byte(s[:n]))
+	return n, err
+}
+
+// tee captures p once the response content type is known to be logged
+func (w *bodyWriter) tee(p []byte) {
+	if !w.checked {
+		w.checked = true
+		w.capture = w.allowed(w.Header().Get("Content-Type"))
+	}
+
+	if w.capture {
+		_, _ = w.body.Write(p)
+	}
+}
+
+// bodyCapture holds the bodies captured for a request
+type bodyCapture struct {
+	config   BodyConfig
+	redactor *log.Redactor
+	request  *cappedBuffer
+	response *bodyWriter
+}
+
+// captureBodies wraps the request body and response writer according to
+// config, returning nil if body logging is disabled
+func captureBodies(ctx *gin.Context, config *BodyConfig) *bodyCapture {
+	if config == nil || (!config.Request && !config.Response) {
+		return nil
+	}
+
+	limit := config.MaxBytes
+	if limit <= 0 {
+		limit = defaultBodyMaxBytes
+	}
+
+	capture := &bodyCapture{config: *config}
+	if len(config.RedactKeys) > 0 {
+		capture.redactor = log.NewRedactor(config.RedactKeys...)
+	}
+
+	if config.Request && ctx.Request.Body != nil && capture.allowed(ctx.GetHeader("Content-Type")) {
+		capture.request = &cappedBuffer{max: limit}
+		ctx.Request.Body = &bodyReader{
+			Reader: io.TeeReader(ctx.Request.Body, capture.request),
+			Closer: ctx.Request.Body,
+		}
+	}
+
+	if config.Response {
+		capture.response = &bodyWriter{
+			ResponseWriter: ctx.Writer,
+			body:           &cappedBuffer{max: limit},
+			allowed:        capture.allowed,
+		}
+		ctx.Writer = capture.response
+	}
+
+	return capture
+}
+
+// allowed reports whether bodies of the content type are logged
+func (c *bodyCapture) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	allowlist := c.config.ContentTypes
+	if len(allowlist) == 0 {
+		allowlist = defaultBodyContentTypes
+	}
+
+	return slices.ContainsFunc(allowlist, func(allowed string) bool {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			return strings.HasPrefix(mediaType, prefix+"/")
+		}
+		return strings.EqualFold(mediaType, allowed)
+	})
+}
+
+// attrs returns the captured bodies as attributes if the response status is
+// within the configured range
+func (c *bodyCapture) attrs(ctx *gin.Context) []slog.Attr {
+	if c == nil {
+		return nil
+	}
+
+	status := ctx.Writer.Status()
+	if (c.config.MinStatus > 0 && status < c.config.MinStatus) || (c.config.MaxStatus > 0 && status > c.config.MaxStatus) {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	if c.request != nil && c.request.buf.Len() > 0 {
+		attrs = append(attrs, c.bodyAttrs("http.request.body.", ctx.GetHeader("Content-Type"), c.request)...)
+	}
+	if c.response != nil && c.response.capture && c.response.body.buf.Len() > 0 {
+		attrs = append(attrs, c.bodyAttrs("http.response.body.", c.response.Header().Get("Content-Type"), c.response.body)...)
+	}
+	return attrs
+}
+
+// bodyAttrs returns the content of a captured body, with sensitive fields
+// masked, and whether it was truncated
+func (c *bodyCapture) bodyAttrs(prefix, contentType string, body *cappedBuffer) []slog.Attr {
+	attrs := []slog.Attr{slog.String(prefix+"content", c.redactBody(contentType, body))}
+	if body.truncated {
+		attrs = append(attrs, slog.Bool(prefix+"truncated", true))
+	}
+	return attrs
+}
+
+// redactBody masks sensitive fields of JSON and form bodies
+func (c *bodyCapture) redactBody(contentType string, body *cappedBuffer) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	redactors := []*log.Redactor{log.Default().Redactor(), c.redactor}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if body.truncated {
+			return truncatedJSONBody
+		}
+		return redactJSON(body.buf.Bytes(), redactors)
+	case mediaType == "application/x-www-form-urlencoded":
+		form := body.buf.String()
+		for _, r := range redactors {
+			form = r.Query(form)
+		}
+		return form
+	default:
+		return body.buf.String()
+	}
+}
+
+// redactJSON masks the values of sensitive keys at any depth of a JSON
+// document. Documents that cannot be parsed are not logged.
+func redactJSON(data []byte, redactors []*log.Redactor) string {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "[INVALID JSON NOT LOGGED]"
+	}
+
+	// Wrap the document so that arrays and scalars at the top level are
+	// redacted like nested values
+	for _, r := range redactors {
+		document = r.Fields(map[string]any{"document": document})["document"]
+	}
+
+	redacted, err := json.Marshal(document)
+	if err != nil {
+		return "[INVALID JSON NOT LOGGED]"
+	}
+	return string(redacted)
+}
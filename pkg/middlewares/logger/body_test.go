@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bodyRouter returns a router that logs bodies with config and echoes JSON
+// request bodies
+func bodyRouter(config BodyConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(Config{Body: &config}))
+	router.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET("/file", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte("png-bytes"))
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "upstream unavailable"})
+	})
+	return router
+}
+
+func TestNewLogsRedactedBodies(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	router := bodyRouter(BodyConfig{Request: true, Response: true, RedactKeys: []string{"ssn"}})
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"name":"Ada","password":"hunter2","profile":{"ssn":"123"}}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	// When
+	router.ServeHTTP(resp, req)
+
+	// Then
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "hunter2", "The handler must receive the original body")
+
+	expected := `{"name":"Ada","password":"` + log.RedactedValue + `","profile":{"ssn":"` + log.RedactedValue + `"}}`
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	assert.JSONEq(t, expected, entries[0].Fields["http.request.body.content"].(string))
+	assert.JSONEq(t, expected, entries[0].Fields["http.response.body.content"].(string))
+	assert.NotContains(t, entries[0].Fields, "http.request.body.truncated")
+}
+
+func TestNewTruncatesBodies(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "text body",
+			contentType: "text/plain",
+			body:        "0123456789",
+			expected:    "0123",
+		},
+		{
+			name:        "JSON body cannot be redacted",
+			contentType: "application/json",
+			body:        `{"token":"secret"}`,
+			expected:    truncatedJSONBody,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			rec := logtest.New(t)
+			router := bodyRouter(BodyConfig{Request: true, MaxBytes: 4, ContentTypes: []string{"text/*", "application/json"}})
+
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+
+			// When
+			router.ServeHTTP(resp, req)
+
+			// Then
+			assert.Equal(t, tc.body, resp.Body.String())
+			entries := rec.Entries()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.expected, entries[0].Fields["http.request.body.content"])
+			assert.Equal(t, true, entries[0].Fields["http.request.body.truncated"])
+		})
+	}
+}
+
+func TestNewFiltersBodiesByContentTypeAndStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   BodyConfig
+		path     string
+		expected any
+	}{
+		{
+			name:   "content type not allowed",
+			config: BodyConfig{Response: true},
+			path:   "/file",
+		},
+		{
+			name:     "status within range",
+			config:   BodyConfig{Response: true, MinStatus: 500},
+			path:     "/fail",
+			expected: `{"error":"upstream unavailable"}`,
+		},
+		{
+			name:   "status outside range",
+			config: BodyConfig{Response: true, MaxStatus: 299},
+			path:   "/fail",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			rec := logtest.New(t)
+			router := bodyRouter(tc.config)
+
+			// When
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			// Then
+			entries := rec.Entries()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.expected, entries[0].Fields["http.response.body.content"])
+		})
+	}
+}
+
+func TestNewKeepsStreamingResponses(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(Config{Body: &BodyConfig{Response: true, ContentTypes: []string{"text/plain"}}}))
+
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain")
+		for _, chunk := range []string{"one,", "two,", "three"} {
+			_, _ = c.Writer.WriteString(chunk)
+			c.Writer.Flush()
+		}
+	})
+
+	resp := httptest.NewRecorder()
+
+	// When
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	// Then
+	assert.True(t, resp.Flushed)
+	assert.Equal(t, "one,two,three", resp.Body.String())
+
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "one,two,three", entries[0].Fields["http.response.body.content"])
+}
@@ -1,46 +1,44 @@
 package logger
 
 import (
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestLogFilteredStatusCode_WhenStatusIs5xx_ShouldLogAsError(t *testing.T) {
+func TestStatusLevel_WhenStatusIs5xx_ShouldLogAsError(t *testing.T) {
 	// Given
 	statusCode := 503
-	prefix := "test"
 
 	// When
-	logFilteredStatusCode(statusCode, prefix)
+	level := statusLevel(statusCode)
 
 	// Then
-	assert.GreaterOrEqual(t, statusCode, 500)
-	assert.Less(t, statusCode, 600)
+	assert.Equal(t, slog.LevelError, level)
+	assert.Equal(t, "failed", statusOutcome(statusCode))
 }
 
-func TestLogFilteredStatusCode_WhenStatusIs4xx_ShouldLogAsWarning(t *testing.T) {
+func TestStatusLevel_WhenStatusIs4xx_ShouldLogAsWarning(t *testing.T) {
 	// Given
 	statusCode := 404
-	prefix := "test"
 
 	// When
-	logFilteredStatusCode(statusCode, prefix)
+	level := statusLevel(statusCode)
 
 	// Then
-	assert.GreaterOrEqual(t, statusCode, 400)
-	assert.Less(t, statusCode, 500)
+	assert.Equal(t, slog.LevelWarn, level)
+	assert.Equal(t, "warning", statusOutcome(statusCode))
 }
 
-func TestLogFilteredStatusCode_WhenStatusIs2xx_ShouldLogAsInfo(t *testing.T) {
+func TestStatusLevel_WhenStatusIs2xx_ShouldLogAsInfo(t *testing.T) {
 	// Given
 	statusCode := 200
-	prefix := "test"
 
 	// When
-	logFilteredStatusCode(statusCode, prefix)
+	level := statusLevel(statusCode)
 
 	// Then
-	assert.GreaterOrEqual(t, statusCode, 200)
-	assert.Less(t, statusCode, 300)
+	assert.Equal(t, slog.LevelInfo, level)
+	assert.Equal(t, "completed", statusOutcome(statusCode))
 }
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config represents the configuration for the request logging middleware
+type Config struct {
+	// SkipPaths is a list of request paths or route templates that are not
+	// logged, such as health checks.
+	// Example: []string{"/healthz", "/metrics"}
+	SkipPaths []string
+
+	// Skip, when set, is called after the request is processed and the
+	// request is not logged if it returns true.
+	Skip func(c *gin.Context) bool
+
+	// RequestHeaders is the allowlist of request headers logged as
+	// "http.request.header.<name>". Sensitive headers such as Authorization
+	// are masked by the redactor of the default pkg/log logger.
+	RequestHeaders []string
+
+	// ResponseHeaders is the allowlist of response headers logged as
+	// "http.response.header.<name>".
+	ResponseHeaders []string
+
+	// ClientIP logs the client IP reported by gin as "client.address".
+	ClientIP bool
+
+	// UserAgent logs the User-Agent header as "user_agent.original".
+	UserAgent bool
+
+	// BodySizes logs the request and response body sizes in bytes as
+	// "http.request.body.size" and "http.response.body.size".
+	BodySizes bool
+
+	// Body, when set, logs request and response bodies up to a size limit.
+	// Body logging is meant for debugging integrations and is disabled by
+	// default.
+	Body *BodyConfig
+
+	// Level, when set, selects the level of each entry instead of the
+	// status-based default (ERROR for 5xx, WARN for 4xx, INFO otherwise).
+	Level func(c *gin.Context) slog.Level
+
+	// Attributes, when set, is called after the request is processed and
+	// the returned attributes are added to the entry.
+	Attributes func(c *gin.Context) []slog.Attr
+}
+
+// DefaultConfig returns a configuration that logs the standard request
+// attributes for every path. The client IP, user agent and body sizes are
+// opt-in, as they can hold personal data or add volume that existing log
+// pipelines do not expect.
+func DefaultConfig() Config {
+	return Config{}
+}
@@ -3,24 +3,74 @@
 // with appropriate severity levels based on response status codes.
 //
 // Features:
-//   - Automatic trace ID generation and propagation
+//   - W3C Trace Context propagation, with X-Trace-ID as a fallback
 //   - Request/Response timing measurement
 //   - Status code based log levels
 //   - Structured logging with consistent fields
+//   - Configurable skip paths, header capture and custom attributes
 //
 // Basic Usage:
 //
 //	router := gin.New()
 //	router.Use(logger.Middleware())
 //
+// Custom Configuration:
+//
+//	config := logger.DefaultConfig()
+//	config.SkipPaths = []string{"/healthz", "/metrics"}
+//	config.ClientIP = true
+//	config.RequestHeaders = []string{"X-Request-Source"}
+//	config.Attributes = func(c *gin.Context) []slog.Attr {
+//	    return []slog.Attr{slog.String("tenant", c.GetString("tenant"))}
+//	}
+//	router.Use(logger.New(config))
+//
 // Log Fields:
 // Each log entry includes the following structured fields:
 //   - trace_id: Unique identifier for request tracing
+//   - span_id: Identifier of the request's span
+//   - parent_span_id: Span ID of the caller, when received with traceparent
 //   - http.response.status_code: HTTP status code
 //   - http.request.method: HTTP method (GET, POST, etc.)
-//   - http.route: Request path
+//   - http.route: Route template, such as /api/users/:id, when a route matched
+//   - url.path: Request path
 //   - server.address: Server hostname
 //   - http.response.latency: Request processing duration
+//   - url.query: Query string, when present, with sensitive parameters masked
+//     by the redactor of the default pkg/log logger
+//   - gin.errors: Errors attached with c.Error, when present
+//
+// When enabled in the configuration, entries also include:
+//   - client.address: Client IP as reported by gin (ClientIP)
+//   - user_agent.original: User-Agent header (UserAgent)
+//   - http.request.body.size, http.response.body.size: Body sizes in bytes (BodySizes)
+//   - http.request.header.<name>, http.response.header.<name>: Allowlisted
+//     headers, with sensitive values such as Authorization masked
+//     (RequestHeaders, ResponseHeaders)
+//   - http.request.body.content, http.response.body.content: Bodies, when
+//     body logging is enabled (Body)
+//
+// Body Logging:
+// Setting Config.Body logs request and response bodies, which helps when
+// debugging integrations. Bodies are copied while the handler reads and writes
+// them, so streaming requests and responses are neither buffered nor delayed,
+// and only the first MaxBytes of each body are kept:
+//
+//	config := logger.DefaultConfig()
+//	config.Body = &logger.BodyConfig{
+//	    Request:    true,
+//	    Response:   true,
+//	    MaxBytes:   2048,
+//	    MinStatus:  400,
+//	    RedactKeys: []string{"iban"},
+//	}
+//
+// Only bodies of the allowlisted content types are logged, by default JSON,
+// form and plain text. Values of sensitive JSON and form fields are masked
+// with the patterns of the default pkg/log redactor and RedactKeys. JSON
+// bodies longer than MaxBytes cannot be redacted reliably and are replaced
+// with a placeholder, while other truncated bodies are marked with
+// "http.request.body.truncated" or "http.response.body.truncated".
 //
 // Log Levels:
 // The middleware uses different log levels based on the response status:
@@ -28,23 +78,42 @@
 //   - WARN: For client errors (4xx)
 //   - ERROR: For server errors (5xx)
 //
-// Trace ID Handling:
-// The middleware handles trace IDs in the following way:
-//  1. Checks for existing X-Trace-ID in request headers
-//  2. Generates new UUID if no trace ID exists
-//  3. Sets trace ID in Gin context for downstream use
-//  4. Adds trace ID to response headers
+// Config.Level replaces the status-based levels, for example to log 404
+// responses at DEBUG.
+//
+// Trace Context Handling:
+// The middleware propagates W3C Trace Context in the following way:
+//  1. Reads the caller's trace from the traceparent and tracestate headers,
+//     falling back to X-Trace-ID for callers without W3C Trace Context support
+//  2. Starts a new trace if the request carries neither
+//  3. Starts a new span for the request, whose parent is the caller's span
+//  4. Stores the span in the request context (see pkg/tracectx) and the trace
+//     and span IDs in the Gin context ("X-Trace-ID", "X-Span-ID")
+//  5. Adds the trace ID to the X-Trace-ID response header
+//
+// When a tracing middleware such as otel.Middleware is registered before the
+// logger middleware, the span it stored in the request context is used
+// instead of starting a new one.
+//
+// Handlers can include the trace ID in their own entries with the context-aware
+// functions of pkg/log, using either the Gin context or the request context:
+//
+//	log.InfoCtx(c, "user created", map[string]any{"id": id})
+//	log.InfoCtx(c.Request.Context(), "user created", nil)
 //
 // Example Log Output:
 //
 //	{
 //	    "level": "INFO",
 //	    "msg": "incoming request completed",
-//	    "trace_id": "550e8400-e29b-41d4-a716-446655440000",
+//	    "trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+//	    "span_id": "53995c3f42cd8ad8",
+//	    "parent_span_id": "00f067aa0ba902b7",
 //	    "http.response.status_code": 200,
 //	    "http.request.method": "GET",
-//	    "http.route": "/api/users",
+//	    "url.path": "/api/users/42",
 //	    "server.address": "localhost:8080",
-//	    "http.response.latency": "125ms"
+//	    "http.response.latency": "125ms",
+//	    "http.route": "/api/users/:id"
 //	}
 package logger
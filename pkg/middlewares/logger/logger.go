@@ -2,54 +2,178 @@ package logger
 
 import (
 	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-// Middleware returns a Gin middleware for incoming request logging
+// Middleware returns a Gin middleware for incoming request logging with
+// default configuration
 func Middleware() gin.HandlerFunc {
+	return New(DefaultConfig())
+}
+
+// New returns a Gin middleware for incoming request logging with the
+// provided config
+func New(config Config) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		start := time.Now()
 
-		// Get or generate trace ID
-		traceID := ctx.GetHeader("X-Trace-ID")
-		if traceID == "" {
-			traceID = uuid.New().String()
+		// Use the span started by a tracing middleware registered before this
+		// one, or continue the caller's trace from the traceparent or
+		// X-Trace-ID header with a new span for this request
+		span, traced := tracectx.FromContext(ctx.Request.Context())
+		if !traced {
+			parent, _ := tracectx.Extract(ctx.Request.Header)
+			span = parent.Child()
+		}
+
+		// Set trace and span IDs in context for further use
+		ctx.Set("X-Trace-ID", span.TraceID)
+		ctx.Set("X-Span-ID", span.SpanID)
+		ctx.Header("X-Trace-ID", span.TraceID)
+
+		// Carry the span in the request context so that code receiving only
+		// ctx.Request.Context() can log it with the log.*Ctx functions and
+		// the ginhttp client can propagate it
+		if !traced {
+			ctx.Request = ctx.Request.WithContext(tracectx.ContextWithSpan(ctx.Request.Context(), span))
 		}
 
-		// Set trace ID in context for further use
-		ctx.Set("X-Trace-ID", traceID)
-		ctx.Header("X-Trace-ID", traceID)
+		bodies := captureBodies(ctx, config.Body)
 
 		// Process request
 		ctx.Next()
 
-		incoming(ctx, traceID, time.Since(start))
+		if skip(ctx, config) {
+			return
+		}
+
+		attrs := incoming(ctx, config, span, time.Since(start))
+		attrs = append(attrs, headerAttrs("http.request.header.", ctx.Request.Header, config.RequestHeaders)...)
+		attrs = append(attrs, headerAttrs("http.response.header.", ctx.Writer.Header(), config.ResponseHeaders)...)
+		attrs = append(attrs, bodies.attrs(ctx)...)
+		if config.Attributes != nil {
+			attrs = append(attrs, config.Attributes(ctx)...)
+		}
+
+		status := ctx.Writer.Status()
+		level := statusLevel(status)
+		if config.Level != nil {
+			level = config.Level(ctx)
+		}
+
+		slog.LogAttrs(ctx.Request.Context(), level, "incoming request "+statusOutcome(status), attrs...)
+	}
+}
+
+// skip reports whether the request must not be logged
+func skip(ctx *gin.Context, config Config) bool {
+	if slices.Contains(config.SkipPaths, ctx.Request.URL.Path) {
+		return true
+	}
+
+	if route := ctx.FullPath(); route != "" && slices.Contains(config.SkipPaths, route) {
+		return true
+	}
+
+	return config.Skip != nil && config.Skip(ctx)
+}
+
+// incoming returns the attributes of the request entry enabled by config
+func incoming(ctx *gin.Context, config Config, span tracectx.SpanContext, duration time.Duration) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("trace_id", span.TraceID),
+		slog.String("span_id", span.SpanID),
+		slog.Int("http.response.status_code", ctx.Writer.Status()),
+		slog.String("http.request.method", ctx.Request.Method),
+		slog.String("url.path", ctx.Request.URL.Path),
+		slog.String("server.address", ctx.Request.Host),
+		slog.String("http.response.latency", duration.String()),
+	}
+
+	if span.ParentSpanID != "" {
+		attrs = append(attrs, slog.String("parent_span_id", span.ParentSpanID))
+	}
+
+	// The route template groups requests to the same handler, and is empty
+	// for requests that matched no route
+	if route := ctx.FullPath(); route != "" {
+		attrs = append(attrs, slog.String("http.route", route))
+	}
+
+	// Query strings may carry credentials, so they are masked with the
+	// redactor of the default pkg/log logger
+	if query := ctx.Request.URL.RawQuery; query != "" {
+		attrs = append(attrs, slog.String("url.query", log.Default().Redactor().Query(query)))
+	}
+
+	if config.ClientIP {
+		attrs = append(attrs, slog.String("client.address", ctx.ClientIP()))
+	}
+
+	if userAgent := ctx.Request.UserAgent(); config.UserAgent && userAgent != "" {
+		attrs = append(attrs, slog.String("user_agent.original", userAgent))
+	}
+
+	if config.BodySizes {
+		if size := ctx.Request.ContentLength; size >= 0 {
+			attrs = append(attrs, slog.Int64("http.request.body.size", size))
+		}
+		attrs = append(attrs, slog.Int("http.response.body.size", max(ctx.Writer.Size(), 0)))
+	}
+
+	// Errors attached by handlers with ctx.Error
+	if len(ctx.Errors) > 0 {
+		attrs = append(attrs, slog.Any("gin.errors", ctx.Errors.Errors()))
 	}
+
+	return attrs
 }
 
-func incoming(ctx *gin.Context, traceID string, duration time.Duration) {
-	attrs := []any{
-		"trace_id", traceID,
-		"http.response.status_code", ctx.Writer.Status(),
-		"http.request.method", ctx.Request.Method,
-		"http.route", ctx.Request.URL.Path,
-		"server.address", ctx.Request.Host,
-		"http.response.latency", duration.String(),
+// headerAttrs returns the allowlisted headers as attributes with lowercase
+// names, masking sensitive values
+func headerAttrs(prefix string, header http.Header, allowlist []string) []slog.Attr {
+	if len(allowlist) == 0 {
+		return nil
+	}
+
+	redacted := log.Default().Redactor().Header(header)
+
+	var attrs []slog.Attr
+	for _, name := range allowlist {
+		if values := redacted.Values(name); len(values) > 0 {
+			attrs = append(attrs, slog.String(prefix+strings.ToLower(name), strings.Join(values, ", ")))
+		}
 	}
+	return attrs
+}
 
-	logFilteredStatusCode(ctx.Writer.Status(), "incoming request", attrs...)
+// statusLevel returns the level for a response status
+func statusLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
 }
 
-func logFilteredStatusCode(status int, prefix string, attrs ...any) {
+// statusOutcome describes a response status in the log message
+func statusOutcome(status int) string {
 	switch {
 	case status >= 500:
-		slog.Error(prefix+" failed", attrs...)
+		return "failed"
 	case status >= 400:
-		slog.Warn(prefix+" warning", attrs...)
+		return "warning"
 	default:
-		slog.Info(prefix+" completed", attrs...)
+		return "completed"
 	}
 }
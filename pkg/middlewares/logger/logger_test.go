@@ -1,13 +1,23 @@
 package logger
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMiddlewareWithoutTraceID(t *testing.T) {
@@ -51,3 +61,291 @@ func TestMiddlewareWithExistingTraceID(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.Equal(t, existingTraceID, resp.Header().Get("X-Trace-ID"))
 }
+
+func TestMiddlewareAddsTraceIDToRequestContext(t *testing.T) {
+	// Given
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+
+	var ginEntry, requestEntry map[string]any
+	router.GET("/test", func(c *gin.Context) {
+		ginEntry = captureCtxEntry(t, c)
+		requestEntry = captureCtxEntry(t, c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Trace-ID", "test-trace-id")
+
+	// When
+	router.ServeHTTP(resp, req)
+
+	// Then
+	assert.Equal(t, "test-trace-id", ginEntry["trace_id"])
+	assert.Equal(t, "test-trace-id", requestEntry["trace_id"])
+}
+
+func TestMiddlewareContinuesTraceparent(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+
+	var span tracectx.SpanContext
+	router.GET("/test", func(c *gin.Context) {
+		span, _ = tracectx.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "congo=t61rcWkgMzE")
+	req.Header.Set("X-Trace-ID", "ignored-legacy-id")
+
+	// When
+	router.ServeHTTP(resp, req)
+
+	// Then
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID)
+	assert.NotEqual(t, span.ParentSpanID, span.SpanID)
+	assert.True(t, span.IsW3C())
+	assert.Equal(t, "congo=t61rcWkgMzE", span.TraceState)
+	assert.Equal(t, span.TraceID, resp.Header().Get("X-Trace-ID"))
+
+	rec.AssertContains("INFO", "incoming request completed", map[string]any{
+		"trace_id":       span.TraceID,
+		"span_id":        span.SpanID,
+		"parent_span_id": "00f067aa0ba902b7",
+	})
+}
+
+func TestMiddlewareStartsW3CTrace(t *testing.T) {
+	// Given
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+
+	var entry map[string]any
+	router.GET("/test", func(c *gin.Context) {
+		entry = captureCtxEntry(t, c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	resp := httptest.NewRecorder()
+
+	// When
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	// Then
+	traceID := resp.Header().Get("X-Trace-ID")
+	assert.Regexp(t, "^[0-9a-f]{32}$", traceID)
+	assert.Equal(t, traceID, entry["trace_id"])
+	assert.Regexp(t, "^[0-9a-f]{16}$", entry["span_id"])
+}
+
+// captureCtxEntry logs through a buffer-backed logger and returns the fields
+// of the resulting entry
+func captureCtxEntry(t *testing.T, ctx context.Context) map[string]any {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	log.New(log.Options{Output: buf}).InfoCtx(ctx, "handler called", nil)
+
+	var entry struct {
+		Fields map[string]any `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry.Fields
+}
+
+func TestMiddlewareRedactsQueryString(t *testing.T) {
+	// Given
+	buf := &bytes.Buffer{}
+	origLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+	defer slog.SetDefault(origLogger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test?page=2&access_token=secret-value", nil)
+
+	// When
+	router.ServeHTTP(resp, req)
+
+	// Then
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "page=2&access_token="+log.RedactedValue, entry["url.query"])
+	assert.NotContains(t, buf.String(), "secret-value")
+}
+
+func TestMiddlewareOmitsOptInAttributes(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.POST("/users", func(c *gin.Context) {
+		c.String(http.StatusCreated, "created")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"a"}`))
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "golib-test/1.0")
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Then
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	fields := entries[0].Fields
+	assert.Equal(t, "/users", fields["http.route"])
+	assert.NotContains(t, fields, "client.address")
+	assert.NotContains(t, fields, "user_agent.original")
+	assert.NotContains(t, fields, "http.request.body.size")
+	assert.NotContains(t, fields, "http.response.body.size")
+}
+
+func TestNewLogsConfiguredAttributes(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(Config{
+		RequestHeaders:  []string{"X-Request-Source", "authorization"},
+		ResponseHeaders: []string{"Content-Type"},
+		ClientIP:        true,
+		UserAgent:       true,
+		BodySizes:       true,
+		Attributes: func(c *gin.Context) []slog.Attr {
+			return []slog.Attr{slog.String("tenant", c.GetHeader("X-Tenant"))}
+		},
+	}))
+	router.POST("/users/:id", func(c *gin.Context) {
+		_ = c.Error(errors.New("validation failed"))
+		c.String(http.StatusCreated, "created")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/42", strings.NewReader(`{"name":"a"}`))
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "golib-test/1.0")
+	req.Header.Set("X-Request-Source", "mobile")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Tenant", "acme")
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Then
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	fields := entries[0].Fields
+	assert.Equal(t, "INFO", entries[0].Level)
+	assert.Equal(t, "incoming request completed", entries[0].Message)
+	assert.Equal(t, "/users/:id", fields["http.route"])
+	assert.Equal(t, "/users/42", fields["url.path"])
+	assert.Equal(t, "203.0.113.7", fields["client.address"])
+	assert.Equal(t, "golib-test/1.0", fields["user_agent.original"])
+	assert.Equal(t, int64(12), fields["http.request.body.size"])
+	assert.Equal(t, int64(7), fields["http.response.body.size"])
+	assert.Equal(t, "mobile", fields["http.request.header.x-request-source"])
+	assert.Equal(t, log.RedactedValue, fields["http.request.header.authorization"])
+	assert.Equal(t, "text/plain; charset=utf-8", fields["http.response.header.content-type"])
+	assert.Equal(t, []string{"validation failed"}, fields["gin.errors"])
+	assert.Equal(t, "acme", fields["tenant"])
+	assert.NotContains(t, fields, "http.request.header.x-tenant")
+}
+
+func TestNewSkipsConfiguredPaths(t *testing.T) {
+	testCases := []struct {
+		name       string
+		config     Config
+		path       string
+		expectLogs bool
+	}{
+		{
+			name:   "raw path",
+			config: Config{SkipPaths: []string{"/healthz"}},
+			path:   "/healthz",
+		},
+		{
+			name:   "route template",
+			config: Config{SkipPaths: []string{"/internal/:name"}},
+			path:   "/internal/status",
+		},
+		{
+			name: "skip function",
+			config: Config{Skip: func(c *gin.Context) bool {
+				return c.Writer.Status() < http.StatusBadRequest
+			}},
+			path: "/users/1",
+		},
+		{
+			name:       "other paths are logged",
+			config:     Config{SkipPaths: []string{"/healthz"}},
+			path:       "/users/1",
+			expectLogs: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			rec := logtest.New(t)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(New(tc.config))
+			handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+			router.GET("/healthz", handler)
+			router.GET("/internal/:name", handler)
+			router.GET("/users/:id", handler)
+
+			resp := httptest.NewRecorder()
+
+			// When
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			// Then
+			assert.NotEmpty(t, resp.Header().Get("X-Trace-ID"))
+			assert.Equal(t, tc.expectLogs, rec.Count("") == 1)
+		})
+	}
+}
+
+func TestNewUsesLevelHook(t *testing.T) {
+	// Given
+	rec := logtest.New(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(Config{
+		Level: func(c *gin.Context) slog.Level {
+			if c.Writer.Status() == http.StatusNotFound {
+				return slog.LevelDebug
+			}
+			return slog.LevelInfo
+		},
+	}))
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	// Then
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DEBUG", entries[0].Level)
+	assert.Equal(t, "incoming request warning", entries[0].Message)
+	assert.NotContains(t, entries[0].Fields, "http.route")
+	assert.Equal(t, "/missing", entries[0].Fields["url.path"])
+}
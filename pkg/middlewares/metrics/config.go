@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+)
+
+// Config represents the configuration for the metrics middleware
+type Config struct {
+	// Registry receives the metrics. Defaults to metrics.Default(), which is
+	// shared with the ginhttp client and served by Handler. Another registry
+	// is served with gin.WrapH(registry.Handler()).
+	Registry *metrics.Registry
+
+	// SkipPaths is a list of request paths or route templates that are not
+	// measured, such as the metrics endpoint itself.
+	// Example: []string{"/metrics", "/healthz"}
+	SkipPaths []string
+
+	// LatencyBuckets are the upper bounds in seconds of the request duration
+	// histogram. Defaults to metrics.DefaultLatencyBuckets.
+	LatencyBuckets []float64
+
+	// SizeBuckets are the upper bounds in bytes of the request and response
+	// size histograms. Defaults to metrics.DefaultSizeBuckets.
+	SizeBuckets []float64
+}
+
+// DefaultConfig returns a configuration that measures every path except
+// /metrics in the default registry
+func DefaultConfig() Config {
+	return Config{
+		Registry:       metrics.Default(),
+		SkipPaths:      []string{"/metrics"},
+		LatencyBuckets: metrics.DefaultLatencyBuckets,
+		SizeBuckets:    metrics.DefaultSizeBuckets,
+	}
+}
@@ -0,0 +1,53 @@
+// Package metrics provides a Gin middleware that records HTTP server metrics
+// in the Prometheus text exposition format, and a handler serving them.
+//
+// Features:
+//   - Request counts and latency histograms
+//   - In-flight request gauges
+//   - Request and response body size histograms
+//   - Labels by method, route template and status class
+//
+// Basic Usage:
+//
+//	router := gin.New()
+//	router.Use(metrics.Middleware())
+//	router.GET("/metrics", metrics.Handler())
+//
+// Custom Configuration:
+//
+//	config := metrics.DefaultConfig()
+//	config.SkipPaths = []string{"/metrics", "/healthz"}
+//	config.LatencyBuckets = []float64{.01, .05, .1, .5, 1}
+//	router.Use(metrics.New(config))
+//
+// Metrics:
+//   - http_server_requests_total: Counter of requests served
+//   - http_server_request_duration_seconds: Histogram of request durations
+//   - http_server_active_requests: Gauge of requests in progress, labeled by
+//     method and route only
+//   - http_server_request_body_size_bytes: Histogram of request body sizes
+//   - http_server_response_body_size_bytes: Histogram of response body sizes
+//
+// Labels:
+//   - method: HTTP method, or _OTHER for non-standard methods
+//   - route: Route template such as /api/users/:id, or "unmatched" for
+//     requests that matched no route, so that paths with IDs do not create a
+//     series each
+//   - status_class: Response status class such as 2xx or 5xx
+//
+// Registry:
+// Metrics are recorded in the default registry of pkg/metrics, which the
+// ginhttp client also uses, so one endpoint serves both. Config.Registry
+// selects another registry, for example in tests. Handler always serves the
+// default registry, so another registry is served with its own handler:
+//
+//	registry := pkgmetrics.NewRegistry()
+//	router.Use(metrics.New(metrics.Config{Registry: registry}))
+//	router.GET("/metrics", gin.WrapH(registry.Handler()))
+//
+// Example Output:
+//
+//	# HELP http_server_requests_total Number of HTTP requests served.
+//	# TYPE http_server_requests_total counter
+//	http_server_requests_total{method="GET",route="/api/users/:id",status_class="2xx"} 42
+package metrics
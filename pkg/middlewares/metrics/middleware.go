@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so that requests to
+// unknown paths do not create a series each
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels requests with non-standard methods, for the same reason
+const otherMethod = "_OTHER"
+
+// knownMethods are the methods used as label values
+var knownMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// serverMetrics are the metrics recorded by the middleware
+type serverMetrics struct {
+	requests     *metrics.CounterVec
+	duration     *metrics.HistogramVec
+	active       *metrics.GaugeVec
+	requestSize  *metrics.HistogramVec
+	responseSize *metrics.HistogramVec
+}
+
+// newServerMetrics registers the metrics of the middleware
+func newServerMetrics(config Config) serverMetrics {
+	r := config.Registry
+	return serverMetrics{
+		requests: r.Counter("http_server_requests_total",
+			"Number of HTTP requests served.", "method", "route", "status_class"),
+		duration: r.Histogram("http_server_request_duration_seconds",
+			"Duration of HTTP requests in seconds.", config.LatencyBuckets, "method", "route", "status_class"),
+		active: r.Gauge("http_server_active_requests",
+			"Number of HTTP requests in progress.", "method", "route"),
+		requestSize: r.Histogram("http_server_request_body_size_bytes",
+			"Size of HTTP request bodies in bytes.", config.SizeBuckets, "method", "route", "status_class"),
+		responseSize: r.Histogram("http_server_response_body_size_bytes",
+			"Size of HTTP response bodies in bytes.", config.SizeBuckets, "method", "route", "status_class"),
+	}
+}
+
+// Middleware returns a Gin middleware recording request metrics in the
+// default registry with default configuration
+func Middleware() gin.HandlerFunc {
+	return New(DefaultConfig())
+}
+
+// New returns a Gin middleware recording request metrics with the provided
+// config
+func New(config Config) gin.HandlerFunc {
+	if config.Registry == nil {
+		config.Registry = metrics.Default()
+	}
+	if len(config.LatencyBuckets) == 0 {
+		config.LatencyBuckets = metrics.DefaultLatencyBuckets
+	}
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = metrics.DefaultSizeBuckets
+	}
+	m := newServerMetrics(config)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		if slices.Contains(config.SkipPaths, c.Request.URL.Path) || slices.Contains(config.SkipPaths, route) {
+			c.Next()
+			return
+		}
+
+		method := c.Request.Method
+		if !slices.Contains(knownMethods, method) {
+			method = otherMethod
+		}
+
+		// Bodies without a Content-Length, such as chunked uploads, are
+		// measured as the handler reads them
+		var body *countingReader
+		if c.Request.ContentLength < 0 && c.Request.Body != nil {
+			body = &countingReader{ReadCloser: c.Request.Body}
+			c.Request.Body = body
+		}
+
+		start := time.Now()
+		m.active.Inc(method, route)
+		defer m.active.Dec(method, route)
+
+		c.Next()
+
+		requestSize := c.Request.ContentLength
+		if body != nil {
+			requestSize = body.n
+		}
+
+		status := metrics.StatusClass(c.Writer.Status())
+		m.requests.Inc(method, route, status)
+		m.duration.Observe(time.Since(start).Seconds(), method, route, status)
+		m.requestSize.Observe(float64(max(requestSize, 0)), method, route, status)
+		m.responseSize.Observe(float64(max(c.Writer.Size(), 0)), method, route, status)
+	}
+}
+
+// Handler returns a Gin handler serving the default registry in the
+// Prometheus text exposition format. It ignores Config.Registry; metrics
+// recorded in another registry are served with gin.WrapH(registry.Handler()).
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(metrics.Default().Handler())
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read implements io.Reader
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/CloudLearnersOrg/golib/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter returns a router measured into a separate registry
+func newTestRouter(t *testing.T) (*gin.Engine, *metrics.Registry) {
+	t.Helper()
+
+	registry := metrics.NewRegistry()
+	config := DefaultConfig()
+	config.Registry = registry
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(config))
+	router.GET("/metrics", gin.WrapH(registry.Handler()))
+	router.POST("/users/:id", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, "created %d bytes", len(body))
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	return router, registry
+}
+
+func TestMiddlewareRecordsRequestMetrics(t *testing.T) {
+	// Given
+	router, registry := newTestRouter(t)
+	m := newServerMetrics(Config{Registry: registry})
+
+	// When
+	for _, id := range []string{"1", "2"} {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+id, strings.NewReader(`{"name":"Ada"}`))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown/path", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/fail", nil))
+
+	// Then
+	assert.Equal(t, float64(2), m.requests.Value("POST", "/users/:id", "2xx"))
+	assert.Equal(t, float64(1), m.requests.Value("GET", "/fail", "5xx"))
+	assert.Equal(t, float64(1), m.requests.Value("GET", unmatchedRoute, "4xx"))
+	assert.Equal(t, float64(1), m.requests.Value(otherMethod, unmatchedRoute, "4xx"))
+
+	count, sum := m.requestSize.Count("POST", "/users/:id", "2xx")
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, float64(28), sum)
+
+	count, sum = m.responseSize.Count("POST", "/users/:id", "2xx")
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, float64(len("created 14 bytes")*2), sum)
+
+	count, _ = m.duration.Count("GET", "/fail", "5xx")
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, float64(0), m.active.Value("POST", "/users/:id"))
+}
+
+func TestMiddlewareCountsChunkedRequestBodies(t *testing.T) {
+	// Given
+	router, registry := newTestRouter(t)
+	m := newServerMetrics(Config{Registry: registry})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", iotest.OneByteReader(strings.NewReader("chunked")))
+	req.ContentLength = -1
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Then
+	_, sum := m.requestSize.Count("POST", "/users/:id", "2xx")
+	assert.Equal(t, float64(len("chunked")), sum)
+}
+
+func TestMiddlewareTracksActiveRequests(t *testing.T) {
+	// Given
+	registry := metrics.NewRegistry()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(Config{Registry: registry}))
+
+	var active float64
+	router.GET("/slow", func(c *gin.Context) {
+		active = newServerMetrics(Config{Registry: registry}).active.Value("GET", "/slow")
+		c.Status(http.StatusOK)
+	})
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	// Then
+	assert.Equal(t, float64(1), active)
+}
+
+func TestMetricsEndpointServesTextFormat(t *testing.T) {
+	// Given
+	router, _ := newTestRouter(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader("{}")))
+	resp := httptest.NewRecorder()
+
+	// When
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Then
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, metrics.ContentType, resp.Header().Get("Content-Type"))
+	body := resp.Body.String()
+	assert.Contains(t, body, "# TYPE http_server_requests_total counter\n")
+	assert.Contains(t, body, `http_server_requests_total{method="POST",route="/users/:id",status_class="2xx"} 1`)
+	assert.Contains(t, body, `http_server_request_duration_seconds_bucket{method="POST",route="/users/:id",status_class="2xx",le="+Inf"} 1`)
+	assert.NotContains(t, body, `route="/metrics"`, "The metrics endpoint is skipped by default")
+}
+
+func TestNewDefaultsZeroConfig(t *testing.T) {
+	// Given
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(Config{}))
+	router.POST("/upload", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload")))
+
+	// Then
+	var out strings.Builder
+	require.NoError(t, metrics.Default().WriteText(&out))
+	body := out.String()
+	for _, le := range []string{"100", "1000", "10000", "100000", "1e+06", "1e+07", "+Inf"} {
+		assert.Contains(t, body, `http_server_request_body_size_bytes_bucket{method="POST",route="/upload",status_class="2xx",le="`+le+`"} 1`)
+	}
+	assert.NotContains(t, body, `http_server_request_body_size_bytes_bucket{method="POST",route="/upload",status_class="2xx",le="0.005"}`)
+	assert.Contains(t, body, `http_server_request_duration_seconds_bucket{method="POST",route="/upload",status_class="2xx",le="0.005"}`)
+}
@@ -0,0 +1,38 @@
+package signature
+
+import "time"
+
+// KeyIDKey is the Gin context key under which the middleware stores the key
+// ID of verified requests, or an empty string for the default secret
+const KeyIDKey = "signature_key_id"
+
+// Config represents the configuration for the signature middleware
+type Config struct {
+	// Secret verifies requests without an X-Signature-Key-ID header
+	Secret []byte
+
+	// Secrets verifies requests by the key ID sent in the
+	// X-Signature-Key-ID header, so that clients can have their own secret
+	// and secrets can be rotated.
+	// Example: map[string][]byte{"billing": billingSecret}
+	Secrets map[string][]byte
+
+	// MaxSkew is the largest difference between the signing time and the
+	// time of verification, which bounds how long a captured request can
+	// be replayed. Defaults to 5 minutes.
+	MaxSkew time.Duration
+
+	// MaxBodyBytes limits the size of the bodies read to verify their
+	// digest. Larger requests are rejected with 413. Defaults to 10 MiB.
+	MaxBodyBytes int64
+}
+
+// DefaultConfig returns a configuration verifying requests signed with
+// secret
+func DefaultConfig(secret []byte) Config {
+	return Config{
+		Secret:       secret,
+		MaxSkew:      5 * time.Minute,
+		MaxBodyBytes: 10 << 20,
+	}
+}
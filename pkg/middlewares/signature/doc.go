@@ -0,0 +1,42 @@
+// Package signature provides a Gin middleware that verifies requests signed
+// with HMAC-SHA256 by the ginhttp.HMACSigner client middleware, for
+// service-to-service calls.
+//
+// Basic Usage:
+//
+//	router := gin.New()
+//	router.Use(signature.New(signature.DefaultConfig(secret)))
+//
+// The calling service signs its requests with the same secret:
+//
+//	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+//		Middleware: []ginhttp.Middleware{
+//			ginhttp.HMACSigner(ginhttp.HMACConfig{Secret: secret}),
+//		},
+//	})
+//
+// Signed Requests:
+// The signature covers the method, the path and query, the signing time
+// and the SHA-256 digest of the body, sent in the following headers:
+//   - X-Signature: The hex-encoded HMAC-SHA256 signature
+//   - X-Signature-Timestamp: The signing time in Unix seconds
+//   - X-Content-SHA256: The hex-encoded SHA-256 digest of the body
+//   - X-Signature-Key-ID: The key ID, when the client has one
+//
+// Requests signed more than MaxSkew before or after the time of verification
+// are rejected, which bounds how long a captured request can be replayed.
+// Requests with a missing or invalid signature are rejected with 401, and
+// bodies larger than MaxBodyBytes with 413. Verified bodies remain readable
+// by handlers.
+//
+// Multiple Keys:
+// Secrets maps key IDs to secrets, so that each calling service has its own
+// secret and secrets can be rotated. The key ID of verified requests is
+// stored in the Gin context under KeyIDKey:
+//
+//	config := signature.DefaultConfig(nil)
+//	config.Secrets = map[string][]byte{"billing": billingSecret, "orders": ordersSecret}
+//	router.Use(signature.New(config))
+//
+//	caller := c.GetString(signature.KeyIDKey)
+package signature
@@ -0,0 +1,123 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/ginhttp"
+	statuses "github.com/CloudLearnersOrg/golib/pkg/ginhttp/gin/statuses"
+	"github.com/gin-gonic/gin"
+)
+
+// Verification errors, returned in the error field of 401 responses
+var (
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrUnknownKey       = errors.New("unknown signature key")
+	ErrInvalidTimestamp = errors.New("invalid signature timestamp")
+	ErrExpiredSignature = errors.New("signature timestamp is outside the allowed skew")
+	ErrDigestMismatch   = errors.New("body digest does not match the body")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// errBodyTooLarge rejects requests larger than MaxBodyBytes
+var errBodyTooLarge = errors.New("request body is too large")
+
+// New creates a middleware rejecting requests without a valid HMAC-SHA256
+// signature, as set by the ginhttp.HMACSigner client middleware
+func New(config Config) gin.HandlerFunc {
+	defaults := DefaultConfig(nil)
+	if config.MaxSkew <= 0 {
+		config.MaxSkew = defaults.MaxSkew
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+
+	return func(c *gin.Context) {
+		keyID, err := verify(c.Request, config, time.Now())
+		switch {
+		case errors.Is(err, errBodyTooLarge):
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, statuses.Response{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: "Request body too large",
+				Error:   err.Error(),
+			})
+			return
+		case err != nil:
+			statuses.StatusUnauthorized(c, "Invalid request signature", err)
+			return
+		}
+
+		c.Set(KeyIDKey, keyID)
+		c.Next()
+	}
+}
+
+// verify checks the signature of the request, returning the key ID used. The
+// body is read and replaced so that handlers can still read it.
+func verify(req *http.Request, config Config, now time.Time) (string, error) {
+	signature := req.Header.Get(ginhttp.SignatureHeader)
+	timestamp := req.Header.Get(ginhttp.SignatureTimestampHeader)
+	digest := req.Header.Get(ginhttp.ContentSHA256Header)
+	if signature == "" || timestamp == "" || digest == "" {
+		return "", ErrMissingSignature
+	}
+
+	keyID := req.Header.Get(ginhttp.SignatureKeyIDHeader)
+	secret := config.Secret
+	if keyID != "" {
+		secret = config.Secrets[keyID]
+	}
+	if len(secret) == 0 {
+		return "", ErrUnknownKey
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", ErrInvalidTimestamp
+	}
+	if skew := now.Sub(time.Unix(signedAt, 0)).Abs(); skew > config.MaxSkew {
+		return "", ErrExpiredSignature
+	}
+
+	// The signature is checked before the body is read, so that unsigned
+	// requests cannot make the server read large bodies
+	expected := ginhttp.Signature(secret, req.Method, req.URL.RequestURI(), timestamp, digest)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", ErrInvalidSignature
+	}
+
+	body, err := readBody(req, config.MaxBodyBytes)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(digest), []byte(ginhttp.BodyDigest(body))) {
+		return "", ErrDigestMismatch
+	}
+
+	return keyID, nil
+}
+
+// readBody reads the request body, up to limit bytes, and replaces it with
+// a copy
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, errBodyTooLarge
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
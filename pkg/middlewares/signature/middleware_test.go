@@ -0,0 +1,154 @@
+package signature
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/ginhttp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("shared-secret")
+
+// setupRouter returns a router verifying signatures and echoing the body and
+// key ID of verified requests
+func setupRouter(config Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(config))
+	router.Any("/orders", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, c.GetString(KeyIDKey)+":"+string(body))
+	})
+	return router
+}
+
+// signedRequest returns a request signed like ginhttp.HMACSigner, at the
+// given time
+func signedRequest(method, target, body string, signedAt time.Time) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	digest := ginhttp.BodyDigest([]byte(body))
+
+	req.Header.Set(ginhttp.SignatureTimestampHeader, timestamp)
+	req.Header.Set(ginhttp.ContentSHA256Header, digest)
+	req.Header.Set(ginhttp.SignatureHeader, ginhttp.Signature(secret, method, req.URL.RequestURI(), timestamp, digest))
+	return req
+}
+
+func TestMiddlewareVerifiesSignedClientRequests(t *testing.T) {
+	// Given
+	config := DefaultConfig(nil)
+	config.Secrets = map[string][]byte{"billing": secret}
+	server := httptest.NewServer(setupRouter(config))
+	defer server.Close()
+
+	client := ginhttp.NewClientWithOptions(nil, ginhttp.Options{
+		Middleware: []ginhttp.Middleware{
+			ginhttp.HMACSigner(ginhttp.HMACConfig{KeyID: "billing", Secret: secret}),
+		},
+	})
+
+	// When
+	resp, err := client.Request(context.Background(), http.MethodPost, server.URL+"/orders?dry_run=true",
+		strings.NewReader(`{"id":42}`), nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Then
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `billing:{"id":42}`, string(body), "the key ID is stored and the body remains readable")
+}
+
+func TestMiddlewareRejectsInvalidRequests(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name           string
+		request        func() *http.Request
+		expectedStatus int
+		expectedError  error
+	}{
+		{
+			name: "unsigned",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/orders", nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  ErrMissingSignature,
+		},
+		{
+			name: "tampered body",
+			request: func() *http.Request {
+				req := signedRequest(http.MethodPost, "/orders", `{"amount":1}`, now)
+				req.Body = io.NopCloser(strings.NewReader(`{"amount":1000}`))
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  ErrDigestMismatch,
+		},
+		{
+			name: "tampered query",
+			request: func() *http.Request {
+				req := signedRequest(http.MethodGet, "/orders?limit=1", "", now)
+				req.URL.RawQuery = "limit=1000"
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  ErrInvalidSignature,
+		},
+		{
+			name: "expired",
+			request: func() *http.Request {
+				return signedRequest(http.MethodGet, "/orders", "", now.Add(-10*time.Minute))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  ErrExpiredSignature,
+		},
+		{
+			name: "unknown key",
+			request: func() *http.Request {
+				req := signedRequest(http.MethodGet, "/orders", "", now)
+				req.Header.Set(ginhttp.SignatureKeyIDHeader, "unknown")
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  ErrUnknownKey,
+		},
+		{
+			name: "body too large",
+			request: func() *http.Request {
+				return signedRequest(http.MethodPost, "/orders", strings.Repeat("a", 65), now)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  errBodyTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			config := DefaultConfig(secret)
+			config.MaxBodyBytes = 64
+			router := setupRouter(config)
+			w := httptest.NewRecorder()
+
+			// When
+			router.ServeHTTP(w, tc.request())
+
+			// Then
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedError.Error())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package otel integrates golib with OpenTelemetry tracing. It creates server
+// spans for Gin requests, client spans for ginhttp requests, query spans for
+// postgres and command spans for redis.
+//
+// Features:
+//   - Gin middleware continuing the caller's trace from the traceparent header
+//   - ginhttp client whose requests propagate their client span
+//   - pgx query tracer for postgres.Connection
+//   - go-redis hook for redis.Connection
+//   - Span IDs shared with pkg/tracectx, so request logs match the spans
+//
+// Basic Usage:
+//
+//	opts := otel.Options{TracerProvider: provider}
+//
+//	router := gin.New()
+//	router.Use(otel.Middleware(opts), logger.Middleware())
+//
+//	client := otel.NewClient(nil, opts)
+//
+//	// With the optional behaviors of ginhttp
+//	client := otel.NewClientWithOptions(nil, opts, ginhttp.Options{
+//	    Retry: &ginhttp.RetryPolicy{MaxAttempts: 3},
+//	})
+//
+//	db, err := postgres.NewDatabase(postgres.Connection{
+//	    Host:        "localhost",
+//	    QueryTracer: otel.PgxTracer(opts),
+//	})
+//
+//	rdb, err := redis.NewRedisClient(redis.Connection{
+//	    Host:  "localhost",
+//	    Hooks: []goredis.Hook{otel.RedisHook(opts)},
+//	})
+//
+// Options:
+// Options.TracerProvider defaults to the global provider set with
+// otel.SetTracerProvider of go.opentelemetry.io/otel, and Options.Propagator
+// to W3C Trace Context and Baggage.
+//
+// Logging Integration:
+// The middleware stores the server span in the request context as a
+// pkg/tracectx span context, so the logger middleware, the *Ctx functions of
+// pkg/log and the ginhttp client use the span's trace and span IDs. It must be
+// registered before the logger middleware.
+//
+// The client creates a span for every attempt above the ginhttp logging
+// transport, through ginhttp.Options.Tracing, so the span ID logged for an
+// outgoing request is the parent span ID seen by the server.
+//
+// Sensitive Data:
+// Spans never record query arguments, redis command arguments or request
+// bodies, and sensitive query parameters of client URLs are masked with the
+// redactor of the default pkg/log logger.
+//
+// Testing:
+// Spans can be asserted with the in-memory exporter of the OpenTelemetry SDK:
+//
+//	exporter := tracetest.NewInMemoryExporter()
+//	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+//	router.Use(otel.Middleware(otel.Options{TracerProvider: provider}))
+//	...
+//	spans := exporter.GetSpans()
+package otel
@@ -0,0 +1,96 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware returns a Gin middleware that creates a server span for every
+// request, continuing the caller's trace. It must be registered before the
+// logger middleware so that request logs carry the span's IDs.
+func Middleware(opts Options) gin.HandlerFunc {
+	tracer := opts.tracer()
+	propagator := opts.propagator()
+
+	return func(c *gin.Context) {
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		parent := trace.SpanContextFromContext(ctx)
+
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ServerAddress(c.Request.Host),
+			semconv.ClientAddress(c.ClientIP()),
+		}
+		if route := c.FullPath(); route != "" {
+			attrs = append(attrs, semconv.HTTPRoute(route))
+		}
+		if userAgent := c.Request.UserAgent(); userAgent != "" {
+			attrs = append(attrs, semconv.UserAgentOriginal(userAgent))
+		}
+
+		ctx, span := tracer.Start(ctx, spanName(c.Request.Method, c.FullPath()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		// Share the span's IDs with the logger middleware, pkg/log and the
+		// ginhttp client
+		ctx = withTraceContext(ctx, span.SpanContext(), parent)
+		c.Set("X-Trace-ID", span.SpanContext().TraceID().String())
+		c.Set("X-Span-ID", span.SpanContext().SpanID().String())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		// Errors attached by handlers with c.Error
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
+
+// spanName returns the name of a server span, using the route template so
+// that requests to the same handler are grouped
+func spanName(method, route string) string {
+	if route == "" {
+		return method
+	}
+	return method + " " + route
+}
+
+// withTraceContext returns a copy of ctx carrying the span's IDs as a
+// pkg/tracectx span context
+func withTraceContext(ctx context.Context, span, parent trace.SpanContext) context.Context {
+	return tracectx.ContextWithSpan(ctx, spanContext(span, parent))
+}
+
+// spanContext returns the IDs of an OpenTelemetry span as a pkg/tracectx
+// span context
+func spanContext(span, parent trace.SpanContext) tracectx.SpanContext {
+	sc := tracectx.SpanContext{
+		TraceID:    span.TraceID().String(),
+		SpanID:     span.SpanID().String(),
+		Sampled:    span.IsSampled(),
+		TraceState: span.TraceState().String(),
+	}
+	if parent.HasSpanID() {
+		sc.ParentSpanID = parent.SpanID().String()
+	}
+	return sc
+}
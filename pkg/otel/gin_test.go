@@ -0,0 +1,104 @@
+package otel
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/CloudLearnersOrg/golib/pkg/middlewares/logger"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestOptions returns options whose spans are recorded in memory
+func newTestOptions(t *testing.T) (Options, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(t.Context()) })
+
+	return Options{TracerProvider: provider}, exporter
+}
+
+// spanAttributes returns the attributes of a recorded span as a map
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestMiddlewareCreatesServerSpans(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	rec := logtest.New(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(opts), logger.Middleware())
+
+	var handlerSpan tracectx.SpanContext
+	router.GET("/users/:id", func(c *gin.Context) {
+		handlerSpan, _ = tracectx.FromContext(c.Request.Context())
+		_ = c.Error(errors.New("database unavailable"))
+		c.Status(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Then
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /users/:id", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, codes.Error, span.Status.Code)
+	require.Len(t, span.Events, 1)
+	assert.Equal(t, "exception", span.Events[0].Name)
+
+	attrs := spanAttributes(span)
+	assert.Equal(t, "/users/:id", attrs["http.route"].AsString())
+	assert.Equal(t, int64(http.StatusServiceUnavailable), attrs["http.response.status_code"].AsInt64())
+
+	// The logger middleware and handlers see the server span's IDs
+	assert.Equal(t, span.SpanContext.SpanID().String(), handlerSpan.SpanID)
+	assert.Equal(t, "00f067aa0ba902b7", handlerSpan.ParentSpanID)
+	rec.AssertContains("ERROR", "incoming request failed", map[string]any{
+		"trace_id": span.SpanContext.TraceID().String(),
+		"span_id":  span.SpanContext.SpanID().String(),
+	})
+}
+
+func TestMiddlewareStartsTraceWithoutParent(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(opts))
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	// Then
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET", spans[0].Name)
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+}
@@ -0,0 +1,148 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/CloudLearnersOrg/golib/pkg/ginhttp"
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewClient returns a ginhttp client whose requests are traced with client
+// spans. The base client is copied, not modified.
+func NewClient(baseClient *http.Client, opts Options) *ginhttp.Client {
+	return NewClientWithOptions(baseClient, opts, ginhttp.Options{})
+}
+
+// NewClientWithOptions returns a ginhttp client with the behaviors enabled in
+// clientOpts, such as retries, whose requests are traced with client spans.
+// The tracing transport is installed as clientOpts.Tracing, replacing any set
+// by the caller, so that every attempt has its own span and is logged with
+// the span's IDs. The base client is copied, not modified.
+func NewClientWithOptions(baseClient *http.Client, opts Options, clientOpts ginhttp.Options) *ginhttp.Client {
+	clientOpts.Tracing = func(next http.RoundTripper) http.RoundTripper {
+		return Transport(next, opts)
+	}
+	return ginhttp.NewClientWithOptions(baseClient, clientOpts)
+}
+
+// Transport returns a round tripper that creates a client span for every
+// request and propagates it to the server
+func Transport(next http.RoundTripper, opts Options) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{
+		next:       next,
+		tracer:     opts.tracer(),
+		propagator: opts.propagator(),
+	}
+}
+
+// transport traces requests made through next
+type transport struct {
+	next       http.RoundTripper
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.ServerAddress(req.URL.Hostname()),
+		semconv.URLFull(redactedURL(req)),
+	}
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+
+	ctx := req.Context()
+	parent := trace.SpanContextFromContext(ctx)
+	if !parent.IsValid() {
+		// Callers without a server span may carry a pkg/tracectx span,
+		// such as the span stored by the logger middleware or the trace
+		// started by ginhttp retries
+		if sc, ok := remoteSpanContext(ctx); ok {
+			parent = sc
+			ctx = trace.ContextWithRemoteSpanContext(ctx, sc)
+		}
+	}
+
+	ctx, span := t.tracer.Start(ctx, req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	// A ginhttp logging transport below logs and propagates this span
+	ctx = ginhttp.ContextWithRequestSpan(ctx, spanContext(span.SpanContext(), parent))
+
+	// A RoundTripper must not modify the request, so the trace headers are
+	// set on a copy
+	req = req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(semconv.ErrorType(err))
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// remoteSpanContext returns the pkg/tracectx span of ctx as an OpenTelemetry
+// span context, when its IDs are W3C IDs
+func remoteSpanContext(ctx context.Context) (trace.SpanContext, bool) {
+	sc, ok := tracectx.FromContext(ctx)
+	if !ok || !sc.IsW3C() {
+		return trace.SpanContext{}, false
+	}
+
+	traceID, err := trace.TraceIDFromHex(sc.TraceID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(sc.SpanID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	var flags trace.TraceFlags
+	if sc.Sampled {
+		flags = trace.FlagsSampled
+	}
+	traceState, _ := trace.ParseTraceState(sc.TraceState)
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		TraceState: traceState,
+		Remote:     true,
+	}), true
+}
+
+// redactedURL returns the request URL with user info removed and sensitive
+// query parameters masked by the redactor of the default pkg/log logger
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = log.Default().Redactor().Query(u.RawQuery)
+	return u.String()
+}
@@ -0,0 +1,145 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CloudLearnersOrg/golib/pkg/ginhttp"
+	"github.com/CloudLearnersOrg/golib/pkg/log"
+	"github.com/CloudLearnersOrg/golib/pkg/log/logtest"
+	"github.com/CloudLearnersOrg/golib/pkg/tracectx"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewClientCreatesClientSpans(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	rec := logtest.New(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(opts))
+	client := NewClient(nil, opts)
+	router.GET("/proxy", func(c *gin.Context) {
+		resp, err := client.OutgoingRequest(c, http.MethodGet, server.URL+"/items?api_key=secret-value", nil, nil)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		c.Status(resp.StatusCode)
+	})
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/proxy", nil))
+
+	// Then
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	clientSpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind)
+	assert.Equal(t, "GET", clientSpan.Name)
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), clientSpan.Parent.SpanID())
+	assert.Equal(t, codes.Error, clientSpan.Status.Code)
+
+	// The server receives the client span as its parent
+	received, err := tracectx.ParseTraceparent(traceparent)
+	require.NoError(t, err)
+	assert.Equal(t, clientSpan.SpanContext.TraceID().String(), received.TraceID)
+	assert.Equal(t, clientSpan.SpanContext.SpanID().String(), received.SpanID)
+
+	// The client span is the one logged for the request
+	rec.AssertContains("INFO", "outgoing request completed", map[string]any{
+		"trace_id":       received.TraceID,
+		"span_id":        received.SpanID,
+		"parent_span_id": serverSpan.SpanContext.SpanID().String(),
+	})
+
+	attrs := spanAttributes(clientSpan)
+	assert.Equal(t, server.URL+"/items?api_key="+log.RedactedValue, attrs["url.full"].AsString())
+	assert.Equal(t, int64(http.StatusNotFound), attrs["http.response.status_code"].AsInt64())
+}
+
+func TestNewClientWithOptionsLogsClientSpans(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	rec := logtest.New(t)
+
+	var parents []tracectx.SpanContext
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, err := tracectx.ParseTraceparent(r.Header.Get("traceparent"))
+		assert.NoError(t, err)
+		parents = append(parents, parent)
+		if len(parents) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(nil, opts, ginhttp.Options{
+		Retry: &ginhttp.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	})
+
+	// When
+	resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// Then every attempt has its own client span, logged with the span ID
+	// the server receives as its parent
+	require.Len(t, parents, 2)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	for i, parent := range parents {
+		assert.Equal(t, spans[i].SpanContext.SpanID().String(), parent.SpanID)
+		assert.Equal(t, spans[i].SpanContext.TraceID().String(), parent.TraceID)
+		rec.AssertContains("INFO", "outgoing request completed", map[string]any{
+			"trace_id": parent.TraceID,
+			"span_id":  parent.SpanID,
+		})
+	}
+	assert.Equal(t, parents[0].TraceID, parents[1].TraceID, "the attempts share a trace")
+	assert.NotEqual(t, parents[0].SpanID, parents[1].SpanID)
+}
+
+func TestTransportRecordsErrors(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	failing := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	client := &http.Client{Transport: Transport(failing, opts)}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://example.invalid/orders", nil)
+	require.NoError(t, err)
+
+	// When
+	_, err = client.Do(req)
+
+	// Then
+	require.Error(t, err)
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "connection refused", spans[0].Status.Description)
+	assert.Empty(t, req.Header.Get("traceparent"), "The caller's request must not be modified")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
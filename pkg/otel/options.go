@@ -0,0 +1,38 @@
+package otel
+
+import (
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this package
+const instrumentationName = "github.com/CloudLearnersOrg/golib/pkg/otel"
+
+// Options configures the instrumentation
+type Options struct {
+	// TracerProvider creates the tracer of the instrumentation. Defaults to
+	// the global provider set with otel.SetTracerProvider.
+	TracerProvider trace.TracerProvider
+
+	// Propagator reads and writes the trace context of HTTP requests.
+	// Defaults to W3C Trace Context and Baggage.
+	Propagator propagation.TextMapPropagator
+}
+
+// tracer returns the tracer of the configured provider
+func (o Options) tracer() trace.Tracer {
+	provider := o.TracerProvider
+	if provider == nil {
+		provider = otelapi.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+// propagator returns the configured propagator
+func (o Options) propagator() propagation.TextMapPropagator {
+	if o.Propagator == nil {
+		return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return o.Propagator
+}
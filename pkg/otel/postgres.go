@@ -0,0 +1,94 @@
+package otel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PgxTracer returns a pgx query tracer that creates a span for every query,
+// batch and copy, to be set as postgres.Connection.QueryTracer. Query
+// arguments are never recorded.
+//
+// pgx v4 reports queries once they complete, so spans are created with the
+// start time derived from the reported duration.
+func PgxTracer(opts Options) pgx.Logger {
+	return &pgxTracer{tracer: opts.tracer()}
+}
+
+// pgxTracer creates spans from the entries pgx logs for completed queries
+type pgxTracer struct {
+	tracer trace.Tracer
+}
+
+// Log implements pgx.Logger, ignoring entries that do not describe a query
+func (t *pgxTracer) Log(ctx context.Context, _ pgx.LogLevel, msg string, data map[string]any) {
+	duration, ok := data["time"].(time.Duration)
+	if !ok {
+		return
+	}
+
+	attrs := []attribute.KeyValue{semconv.DBSystemNamePostgreSQL}
+	name := msg
+
+	switch msg {
+	case "Query", "Exec":
+		sql, _ := data["sql"].(string)
+		attrs = append(attrs, semconv.DBQueryText(sql))
+		if operation := sqlOperation(sql); operation != "" {
+			name = operation
+			attrs = append(attrs, semconv.DBOperationName(operation))
+		}
+	case "SendBatch":
+		name = "BATCH"
+		attrs = append(attrs, semconv.DBOperationName(name))
+		if size, ok := data["batchLen"].(int); ok {
+			attrs = append(attrs, semconv.DBOperationBatchSize(size))
+		}
+	case "CopyFrom":
+		name = "COPY"
+		attrs = append(attrs, semconv.DBOperationName(name))
+		if table, ok := data["tableName"].(pgx.Identifier); ok {
+			attrs = append(attrs, semconv.DBCollectionName(table.Sanitize()))
+		}
+	default:
+		return
+	}
+
+	switch rows := data["rowCount"].(type) {
+	case int:
+		attrs = append(attrs, semconv.DBResponseReturnedRows(rows))
+	case int64:
+		attrs = append(attrs, semconv.DBResponseReturnedRows(int(rows)))
+	}
+
+	end := time.Now()
+	_, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-duration)),
+		trace.WithAttributes(attrs...),
+	)
+
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
+
+// sqlOperation returns the uppercase first keyword of a SQL statement, such
+// as SELECT
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,124 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPgxTracerCreatesQuerySpans(t *testing.T) {
+	testCases := []struct {
+		name          string
+		msg           string
+		data          map[string]any
+		expectedName  string
+		expectedAttrs map[string]any
+		expectedError bool
+	}{
+		{
+			name: "query",
+			msg:  "Query",
+			data: map[string]any{
+				"sql":      "select id from users where email = $1",
+				"args":     []any{"ada@example.com"},
+				"time":     20 * time.Millisecond,
+				"rowCount": 1,
+			},
+			expectedName: "SELECT",
+			expectedAttrs: map[string]any{
+				"db.system.name":            "postgresql",
+				"db.operation.name":         "SELECT",
+				"db.query.text":             "select id from users where email = $1",
+				"db.response.returned_rows": int64(1),
+			},
+		},
+		{
+			name: "failed exec",
+			msg:  "Exec",
+			data: map[string]any{
+				"sql":  "DELETE FROM sessions",
+				"time": time.Millisecond,
+				"err":  errors.New("permission denied"),
+			},
+			expectedName:  "DELETE",
+			expectedAttrs: map[string]any{"db.operation.name": "DELETE"},
+			expectedError: true,
+		},
+		{
+			name:          "batch",
+			msg:           "SendBatch",
+			data:          map[string]any{"batchLen": 3, "time": time.Millisecond},
+			expectedName:  "BATCH",
+			expectedAttrs: map[string]any{"db.operation.batch.size": int64(3)},
+		},
+		{
+			name: "copy",
+			msg:  "CopyFrom",
+			data: map[string]any{
+				"tableName": pgx.Identifier{"public", "users"},
+				"time":      time.Millisecond,
+				"rowCount":  int64(10),
+			},
+			expectedName: "COPY",
+			expectedAttrs: map[string]any{
+				"db.collection.name":        `"public"."users"`,
+				"db.response.returned_rows": int64(10),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			opts, exporter := newTestOptions(t)
+			ctx, parent := opts.tracer().Start(context.Background(), "handler")
+			tracer := PgxTracer(opts)
+
+			// When
+			tracer.Log(ctx, pgx.LogLevelInfo, tc.msg, tc.data)
+			parent.End()
+
+			// Then
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 2)
+			span := spans[0]
+			assert.Equal(t, tc.expectedName, span.Name)
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+			assert.Equal(t, tc.data["time"], span.EndTime.Sub(span.StartTime))
+
+			attrs := spanAttributes(span)
+			for key, expected := range tc.expectedAttrs {
+				assert.Equal(t, expected, attrs[attribute.Key(key)].AsInterface(), key)
+			}
+			assert.NotContains(t, attrs, attribute.Key("db.query.parameter"))
+
+			if tc.expectedError {
+				assert.Equal(t, codes.Error, span.Status.Code)
+			} else {
+				assert.Equal(t, codes.Unset, span.Status.Code)
+			}
+		})
+	}
+}
+
+func TestPgxTracerIgnoresOtherEntries(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	tracer := PgxTracer(opts)
+
+	// When
+	tracer.Log(context.Background(), pgx.LogLevelInfo, "Dialing PostgreSQL server", map[string]any{"host": "localhost"})
+	tracer.Log(context.Background(), pgx.LogLevelInfo, "closed connection", nil)
+
+	// Then
+	assert.Empty(t, exporter.GetSpans())
+}
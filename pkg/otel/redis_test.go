@@ -0,0 +1,77 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedisHookCreatesCommandSpans(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		expectedState codes.Code
+	}{
+		{name: "success", expectedState: codes.Unset},
+		{name: "missing key", err: redis.Nil, expectedState: codes.Unset},
+		{name: "failure", err: errors.New("connection reset"), expectedState: codes.Error},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			opts, exporter := newTestOptions(t)
+			ctx := context.Background()
+			process := RedisHook(opts).ProcessHook(func(context.Context, redis.Cmder) error {
+				return tc.err
+			})
+
+			// When
+			err := process(ctx, redis.NewStringCmd(ctx, "get", "session:secret-id"))
+
+			// Then
+			assert.Equal(t, tc.err, err)
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "get", spans[0].Name)
+			assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+			assert.Equal(t, tc.expectedState, spans[0].Status.Code)
+
+			attrs := spanAttributes(spans[0])
+			assert.Equal(t, "redis", attrs["db.system.name"].AsString())
+			assert.Equal(t, "get", attrs["db.operation.name"].AsString())
+			for _, attr := range spans[0].Attributes {
+				assert.NotContains(t, attr.Value.Emit(), "secret-id")
+			}
+		})
+	}
+}
+
+func TestRedisHookCreatesPipelineSpans(t *testing.T) {
+	// Given
+	opts, exporter := newTestOptions(t)
+	ctx := context.Background()
+	process := RedisHook(opts).ProcessPipelineHook(func(context.Context, []redis.Cmder) error {
+		return nil
+	})
+	cmds := []redis.Cmder{
+		redis.NewStatusCmd(ctx, "set", "a", "1"),
+		redis.NewIntCmd(ctx, "incr", "b"),
+	}
+
+	// When
+	err := process(ctx, cmds)
+
+	// Then
+	require.NoError(t, err)
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "PIPELINE", spans[0].Name)
+	assert.Equal(t, int64(2), spanAttributes(spans[0])["db.operation.batch.size"].AsInt64())
+}
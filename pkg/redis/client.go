@@ -26,6 +26,9 @@ func NewRedisClient(config Connection) (*redis.Client, error) {
 	}
 
 	client := redis.NewClient(opts)
+	for _, hook := range config.Hooks {
+		client.AddHook(hook)
+	}
 
 	// Test the connection using context
 	ctx := context.Background()
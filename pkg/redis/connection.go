@@ -2,6 +2,8 @@ package redis
 
 import (
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // Connection holds Redis connection configuration
@@ -11,6 +13,10 @@ type Connection struct {
 	Password       string
 	Database       int
 	ConnectionPool *ConnectionPool
+
+	// Hooks are added to the client before the connection is tested, such
+	// as the tracing hook returned by otel.RedisHook
+	Hooks []redis.Hook
 }
 
 // ConnectionPool holds Redis connection pool configuration
@@ -55,4 +55,10 @@
 // The package automatically tests the connection during initialization
 // by sending a PING command to Redis. This ensures the connection is
 // valid before returning the client.
+//
+// Hooks:
+// Connection.Hooks are added to the client before the connection is tested,
+// for example to trace commands with OpenTelemetry:
+//
+//	config.Hooks = []goredis.Hook{otel.RedisHook(otel.Options{})}
 package redis
@@ -0,0 +1,34 @@
+// Package tracectx carries trace and span IDs through a context.Context and
+// propagates them between services with the W3C Trace Context headers
+// (traceparent and tracestate).
+//
+// The logger middleware starts a span for every incoming request and the
+// ginhttp client starts a child span for every outgoing request, so most code
+// only reads the IDs:
+//
+//	traceID := tracectx.TraceID(ctx)
+//	spanID := tracectx.SpanID(ctx)
+//
+// Entries logged with the *Ctx functions of pkg/log include both IDs.
+//
+// Propagation:
+// Extract reads the caller's span context from request headers and Inject
+// writes it to outgoing request headers. Each hop is a new span whose parent
+// is the caller's span:
+//
+//	parent, ok := tracectx.Extract(req.Header)
+//	if !ok {
+//	    parent = tracectx.New()
+//	}
+//	span := parent.Child()
+//	ctx = tracectx.ContextWithSpan(ctx, span)
+//	...
+//	tracectx.Inject(outgoing.Header, span.Child())
+//
+// X-Trace-ID Compatibility:
+// Services that do not support W3C Trace Context exchange the trace ID alone
+// in the X-Trace-ID header. Extract falls back to X-Trace-ID when no valid
+// traceparent header is present, and Inject always sets it. Trace IDs received
+// this way are kept verbatim, so they may not be W3C IDs; traceparent is only
+// sent for W3C IDs.
+package tracectx
@@ -0,0 +1,198 @@
+package tracectx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Headers used to propagate span contexts
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+
+	// LegacyTraceIDHeader carries the trace ID alone, for services that do
+	// not support W3C Trace Context
+	LegacyTraceIDHeader = "X-Trace-ID"
+)
+
+const (
+	traceIDLength = 32
+	spanIDLength  = 16
+
+	// traceparentLength is the length of a version 00 traceparent header:
+	// version, trace ID, parent ID and flags separated by dashes
+	traceparentLength = 2 + 1 + traceIDLength + 1 + spanIDLength + 1 + 2
+
+	// maxTraceStateMembers is the number of tracestate list members allowed
+	// by the W3C specification
+	maxTraceStateMembers = 32
+
+	// maxLegacyTraceIDLength bounds X-Trace-ID values, which are logged and
+	// forwarded verbatim
+	maxLegacyTraceIDLength = 128
+
+	sampledFlag = 0x01
+)
+
+// ErrInvalidTraceparent is returned for malformed traceparent headers
+var ErrInvalidTraceparent = errors.New("invalid traceparent header")
+
+// ParseTraceparent parses a traceparent header. The returned span context
+// describes the caller's span: SpanID is the parent ID of the header.
+func ParseTraceparent(header string) (SpanContext, error) {
+	header = strings.TrimSpace(header)
+	if len(header) < traceparentLength {
+		return SpanContext{}, fmt.Errorf("%w: %q is too short", ErrInvalidTraceparent, header)
+	}
+
+	version := header[0:2]
+	switch {
+	case !isLowerHex(version) || version == "ff":
+		return SpanContext{}, fmt.Errorf("%w: unsupported version %q", ErrInvalidTraceparent, version)
+	case version == "00" && len(header) != traceparentLength:
+		return SpanContext{}, fmt.Errorf("%w: unexpected data after flags", ErrInvalidTraceparent)
+	case len(header) > traceparentLength && header[traceparentLength] != '-':
+		// Later versions may append fields after a dash
+		return SpanContext{}, fmt.Errorf("%w: unexpected data after flags", ErrInvalidTraceparent)
+	}
+
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return SpanContext{}, fmt.Errorf("%w: %q is not dash-separated", ErrInvalidTraceparent, header)
+	}
+
+	traceID, spanID, flags := header[3:35], header[36:52], header[53:55]
+	if !validID(traceID, traceIDLength) {
+		return SpanContext{}, fmt.Errorf("%w: invalid trace ID %q", ErrInvalidTraceparent, traceID)
+	}
+	if !validID(spanID, spanIDLength) {
+		return SpanContext{}, fmt.Errorf("%w: invalid parent ID %q", ErrInvalidTraceparent, spanID)
+	}
+	if !isLowerHex(flags) {
+		return SpanContext{}, fmt.Errorf("%w: invalid flags %q", ErrInvalidTraceparent, flags)
+	}
+
+	return SpanContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Sampled: hexDigit(flags[1])&sampledFlag != 0,
+	}, nil
+}
+
+// Traceparent formats the span context as a version 00 traceparent header,
+// or returns an empty string if its IDs are not W3C IDs
+func (sc SpanContext) Traceparent() string {
+	if !sc.IsW3C() {
+		return ""
+	}
+
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags
+}
+
+// Extract returns the caller's span context from request headers. The
+// traceparent and tracestate headers are used when valid, with X-Trace-ID as
+// a fallback for callers that do not support W3C Trace Context.
+func Extract(header http.Header) (SpanContext, bool) {
+	if sc, err := ParseTraceparent(header.Get(TraceparentHeader)); err == nil {
+		sc.TraceState = parseTraceState(header.Values(TracestateHeader))
+		return sc, true
+	}
+
+	traceID := strings.TrimSpace(header.Get(LegacyTraceIDHeader))
+	if traceID == "" || len(traceID) > maxLegacyTraceIDLength || !isPrintable(traceID) {
+		return SpanContext{}, false
+	}
+
+	// X-Trace-ID carries no span or sampling decision, and the caller is
+	// assumed to record its traces like the services that set it today
+	return SpanContext{TraceID: traceID, Sampled: true}, true
+}
+
+// Inject sets the headers propagating the span context to a downstream
+// service. X-Trace-ID is always set for services that do not support W3C
+// Trace Context.
+func Inject(header http.Header, sc SpanContext) {
+	if sc.TraceID == "" {
+		return
+	}
+
+	if traceparent := sc.Traceparent(); traceparent != "" {
+		header.Set(TraceparentHeader, traceparent)
+		if sc.TraceState != "" {
+			header.Set(TracestateHeader, sc.TraceState)
+		} else {
+			header.Del(TracestateHeader)
+		}
+	}
+
+	header.Set(LegacyTraceIDHeader, sc.TraceID)
+}
+
+// parseTraceState combines tracestate header values, discarding the whole
+// state if it is malformed as required by the W3C specification
+func parseTraceState(values []string) string {
+	var members []string
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+
+			key, val, ok := strings.Cut(member, "=")
+			if !ok || key == "" || val == "" || !isPrintable(member) {
+				return ""
+			}
+			members = append(members, member)
+		}
+	}
+
+	if len(members) > maxTraceStateMembers {
+		return ""
+	}
+	return strings.Join(members, ",")
+}
+
+// validID reports whether id is a lowercase hex ID of the given length that
+// is not all zeros
+func validID(id string, length int) bool {
+	return len(id) == length && isLowerHex(id) && strings.Trim(id, "0") != ""
+}
+
+// isLowerHex reports whether s contains only lowercase hex digits
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if hexDigit(s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hexDigit returns the value of a lowercase hex digit, or -1
+func hexDigit(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	default:
+		return -1
+	}
+}
+
+// isPrintable reports whether s contains only printable ASCII characters,
+// so that it can be forwarded in a header and logged safely
+func isPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package tracectx
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		expected SpanContext
+		wantErr  bool
+	}{
+		{
+			name:     "sampled",
+			header:   "00-" + testTraceID + "-" + testSpanID + "-01",
+			expected: SpanContext{TraceID: testTraceID, SpanID: testSpanID, Sampled: true},
+		},
+		{
+			name:     "not sampled",
+			header:   "00-" + testTraceID + "-" + testSpanID + "-00",
+			expected: SpanContext{TraceID: testTraceID, SpanID: testSpanID},
+		},
+		{
+			name:     "future version with extra fields",
+			header:   "cc-" + testTraceID + "-" + testSpanID + "-09-extra",
+			expected: SpanContext{TraceID: testTraceID, SpanID: testSpanID, Sampled: true},
+		},
+		{name: "empty", header: "", wantErr: true},
+		{name: "invalid version", header: "ff-" + testTraceID + "-" + testSpanID + "-01", wantErr: true},
+		{name: "version 00 with extra fields", header: "00-" + testTraceID + "-" + testSpanID + "-01-extra", wantErr: true},
+		{name: "uppercase trace ID", header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01", wantErr: true},
+		{name: "zero trace ID", header: "00-00000000000000000000000000000000-" + testSpanID + "-01", wantErr: true},
+		{name: "zero parent ID", header: "00-" + testTraceID + "-0000000000000000-01", wantErr: true},
+		{name: "missing dash", header: "00_" + testTraceID + "-" + testSpanID + "-01", wantErr: true},
+		{name: "invalid flags", header: "00-" + testTraceID + "-" + testSpanID + "-0x", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			sc, err := ParseTraceparent(tc.header)
+
+			// Then
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidTraceparent) {
+					t.Errorf("Expected ErrInvalidTraceparent, got %v (%+v)", err, sc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if sc != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, sc)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name     string
+		headers  map[string][]string
+		expected SpanContext
+		found    bool
+	}{
+		{
+			name: "traceparent and tracestate",
+			headers: map[string][]string{
+				TraceparentHeader:   {"00-" + testTraceID + "-" + testSpanID + "-01"},
+				TracestateHeader:    {"congo=t61rcWkgMzE", " rojo=00f067aa0ba902b7"},
+				LegacyTraceIDHeader: {"ignored"},
+			},
+			expected: SpanContext{TraceID: testTraceID, SpanID: testSpanID, Sampled: true, TraceState: "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+			found:    true,
+		},
+		{
+			name: "malformed tracestate is discarded",
+			headers: map[string][]string{
+				TraceparentHeader: {"00-" + testTraceID + "-" + testSpanID + "-01"},
+				TracestateHeader:  {"congo"},
+			},
+			expected: SpanContext{TraceID: testTraceID, SpanID: testSpanID, Sampled: true},
+			found:    true,
+		},
+		{
+			name: "X-Trace-ID fallback",
+			headers: map[string][]string{
+				TraceparentHeader:   {"garbage"},
+				LegacyTraceIDHeader: {"legacy-trace"},
+			},
+			expected: SpanContext{TraceID: "legacy-trace", Sampled: true},
+			found:    true,
+		},
+		{
+			name:    "non-printable X-Trace-ID",
+			headers: map[string][]string{LegacyTraceIDHeader: {"bad\x00id"}},
+		},
+		{
+			name: "no headers",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			header := http.Header{}
+			for name, values := range tc.headers {
+				for _, value := range values {
+					header.Add(name, value)
+				}
+			}
+
+			// When
+			sc, found := Extract(header)
+
+			// Then
+			if found != tc.found || sc != tc.expected {
+				t.Errorf("Expected %+v, %v, got %+v, %v", tc.expected, tc.found, sc, found)
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	t.Run("W3C span context", func(t *testing.T) {
+		// Given
+		header := http.Header{TracestateHeader: {"stale=1"}}
+		sc := SpanContext{TraceID: testTraceID, SpanID: testSpanID, TraceState: "congo=t61rcWkgMzE"}
+
+		// When
+		Inject(header, sc)
+
+		// Then
+		if got := header.Get(TraceparentHeader); got != "00-"+testTraceID+"-"+testSpanID+"-00" {
+			t.Errorf("Unexpected traceparent %q", got)
+		}
+		if got := header.Get(TracestateHeader); got != "congo=t61rcWkgMzE" {
+			t.Errorf("Unexpected tracestate %q", got)
+		}
+		if got := header.Get(LegacyTraceIDHeader); got != testTraceID {
+			t.Errorf("Unexpected X-Trace-ID %q", got)
+		}
+	})
+
+	t.Run("legacy trace ID", func(t *testing.T) {
+		// Given
+		header := http.Header{}
+		sc := SpanContext{TraceID: "legacy-trace", SpanID: NewSpanID()}
+
+		// When
+		Inject(header, sc)
+
+		// Then
+		if got := header.Get(TraceparentHeader); got != "" {
+			t.Errorf("Expected no traceparent, got %q", got)
+		}
+		if got := header.Get(LegacyTraceIDHeader); got != "legacy-trace" {
+			t.Errorf("Unexpected X-Trace-ID %q", got)
+		}
+	})
+}
+
+func TestInjectExtractRoundTrip(t *testing.T) {
+	// Given
+	header := http.Header{}
+	sent := New().Child()
+
+	// When
+	Inject(header, sent)
+	received, found := Extract(header)
+
+	// Then
+	if !found || received.TraceID != sent.TraceID || received.SpanID != sent.SpanID || !received.Sampled {
+		t.Errorf("Sent %+v, received %+v", sent, received)
+	}
+}
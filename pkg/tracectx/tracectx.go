@@ -0,0 +1,106 @@
+package tracectx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// contextKey is used to store the span context in a context.Context
+type contextKey struct{}
+
+// SpanContext identifies the current span of a trace
+type SpanContext struct {
+	// TraceID is shared by every span of a trace. It is 32 lowercase hex
+	// characters, or the verbatim X-Trace-ID value for traces started by
+	// services that do not support W3C Trace Context.
+	TraceID string
+
+	// SpanID identifies the span as 16 lowercase hex characters.
+	SpanID string
+
+	// ParentSpanID is the span ID of the caller, empty for the first span
+	// of a trace.
+	ParentSpanID string
+
+	// Sampled reports whether the caller records the trace.
+	Sampled bool
+
+	// TraceState carries vendor-specific trace data, forwarded unchanged.
+	TraceState string
+}
+
+// New returns the first span of a new trace
+func New() SpanContext {
+	return SpanContext{
+		TraceID: NewTraceID(),
+		SpanID:  NewSpanID(),
+		Sampled: true,
+	}
+}
+
+// Child returns a new span of the same trace whose parent is sc. A new trace
+// is started if sc has no trace ID.
+func (sc SpanContext) Child() SpanContext {
+	if sc.TraceID == "" {
+		return New()
+	}
+
+	return SpanContext{
+		TraceID:      sc.TraceID,
+		SpanID:       NewSpanID(),
+		ParentSpanID: sc.SpanID,
+		Sampled:      sc.Sampled,
+		TraceState:   sc.TraceState,
+	}
+}
+
+// IsW3C reports whether the trace and span IDs can be propagated with the
+// traceparent header
+func (sc SpanContext) IsW3C() bool {
+	return validID(sc.TraceID, traceIDLength) && validID(sc.SpanID, spanIDLength)
+}
+
+// NewTraceID returns a random W3C trace ID
+func NewTraceID() string {
+	return randomID(traceIDLength / 2)
+}
+
+// NewSpanID returns a random W3C span ID
+func NewSpanID() string {
+	return randomID(spanIDLength / 2)
+}
+
+// randomID returns n random bytes as lowercase hex
+func randomID(n int) string {
+	id := make([]byte, n)
+	_, _ = rand.Read(id) // never fails, see crypto/rand.Read
+	return hex.EncodeToString(id)
+}
+
+// ContextWithSpan returns a copy of ctx carrying the span context
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// FromContext returns the span context stored in ctx
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// TraceID returns the trace ID stored in ctx, or an empty string
+func TraceID(ctx context.Context) string {
+	sc, _ := FromContext(ctx)
+	return sc.TraceID
+}
+
+// SpanID returns the span ID stored in ctx, or an empty string
+func SpanID(ctx context.Context) string {
+	sc, _ := FromContext(ctx)
+	return sc.SpanID
+}
@@ -0,0 +1,66 @@
+package tracectx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewStartsSampledW3CTrace(t *testing.T) {
+	// When
+	sc := New()
+
+	// Then
+	if !sc.IsW3C() || !sc.Sampled || sc.ParentSpanID != "" {
+		t.Errorf("Unexpected root span %+v", sc)
+	}
+	if other := New(); other.TraceID == sc.TraceID || other.SpanID == sc.SpanID {
+		t.Errorf("IDs are not random: %+v, %+v", sc, other)
+	}
+}
+
+func TestChild(t *testing.T) {
+	t.Run("keeps the trace and links the parent", func(t *testing.T) {
+		// Given
+		parent := SpanContext{TraceID: testTraceID, SpanID: testSpanID, TraceState: "congo=1"}
+
+		// When
+		child := parent.Child()
+
+		// Then
+		if child.TraceID != testTraceID || child.ParentSpanID != testSpanID || child.TraceState != "congo=1" || child.Sampled {
+			t.Errorf("Unexpected child %+v", child)
+		}
+		if child.SpanID == testSpanID || !child.IsW3C() {
+			t.Errorf("Child has no new span ID: %+v", child)
+		}
+	})
+
+	t.Run("starts a trace without a parent", func(t *testing.T) {
+		// When
+		child := SpanContext{}.Child()
+
+		// Then
+		if !child.IsW3C() || child.ParentSpanID != "" {
+			t.Errorf("Unexpected child %+v", child)
+		}
+	})
+}
+
+func TestContext(t *testing.T) {
+	// Given
+	sc := New()
+
+	// When
+	ctx := ContextWithSpan(context.Background(), sc)
+
+	// Then
+	if got, ok := FromContext(ctx); !ok || got != sc {
+		t.Errorf("Expected %+v, got %+v", sc, got)
+	}
+	if TraceID(ctx) != sc.TraceID || SpanID(ctx) != sc.SpanID {
+		t.Errorf("Unexpected IDs %q, %q", TraceID(ctx), SpanID(ctx))
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("Found a span context in an empty context")
+	}
+}